@@ -12,6 +12,11 @@ const (
 
 	CodeNeedLogin
 	CodeInvalidToken
+
+	CodePostNotExist
+	CodeNoPermission
+	CodeCommentNotExist
+	CodeVoteRepeated
 )
 
 var codeMsgMap = map[ResCode]string{
@@ -24,6 +29,11 @@ var codeMsgMap = map[ResCode]string{
 
 	CodeNeedLogin:    "需要登录",
 	CodeInvalidToken: "无效的token",
+
+	CodePostNotExist:    "帖子不存在",
+	CodeNoPermission:    "没有权限",
+	CodeCommentNotExist: "评论不存在",
+	CodeVoteRepeated:    "不允许重复投票",
 }
 
 func (c ResCode) Msg() string {
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/logic"
+	"bluebell/models"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ---- 跟评论相关的 ----
+
+// CreateCommentHandler 发表评论的处理函数
+func CreateCommentHandler(c *gin.Context) {
+	// 1. 获取参数及参数的校验
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("create comment with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamCreateComment)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("create comment with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	// 2. 创建评论
+	comment, err := logic.CreateComment(userID, pid, p)
+	if err != nil {
+		zap.L().Error("logic.CreateComment failed", zap.Int64("pid", pid), zap.Error(err))
+		responseCommentError(c, err)
+		return
+	}
+	// 3. 返回响应
+	ResponseSuccess(c, comment)
+}
+
+// GetCommentListHandler 获取评论列表的处理函数
+// GET请求参数(query string)：/api/v1/post/:id/comments?parent_id=0&page=1&size=10&order=time
+func GetCommentListHandler(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("get comment list with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := &models.ParamCommentList{
+		PostID: pid,
+		Page:   1,
+		Size:   10,
+		Order:  models.OrderTime,
+	}
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("GetCommentListHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	// 获取数据
+	data, err := logic.GetCommentList(p)
+	if err != nil {
+		zap.L().Error("logic.GetCommentList() failed", zap.Error(err))
+		responseCommentError(c, err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// CommentVoteHandler 为评论投票的处理函数
+func CommentVoteHandler(c *gin.Context) {
+	// 参数校验
+	p := new(models.ParamCommentVoteData)
+	if err := c.ShouldBindJSON(p); err != nil {
+		errs, ok := err.(validator.ValidationErrors) // 类型断言
+		if !ok {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		errData := removeTopStruct(errs.Translate(trans)) // 翻译并去除掉错误提示中的结构体标识
+		ResponseErrorWithMsg(c, CodeInvalidParam, errData)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.VoteForComment(userID, p); err != nil {
+		zap.L().Error("logic.VoteForComment() failed", zap.Error(err))
+		responseCommentError(c, err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// responseCommentError 把评论相关的错误转换成响应
+func responseCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mysql.ErrorPostNotExist):
+		ResponseError(c, CodePostNotExist)
+	case errors.Is(err, mysql.ErrorCommentNotExist), errors.Is(err, mysql.ErrorInvalidID):
+		ResponseError(c, CodeCommentNotExist)
+	case errors.Is(err, mysql.ErrorVoteRepeated):
+		ResponseError(c, CodeVoteRepeated)
+	default:
+		ResponseError(c, CodeServerBusy)
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentHandlerParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/api/v1/post/:id/comments", GetCommentListHandler)
+	r.POST("/api/v1/post/:id/comments", CreateCommentHandler)
+
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		code   ResCode
+	}{
+		{http.MethodGet, "/api/v1/post/abc/comments", ``, CodeInvalidParam},
+		{http.MethodGet, "/api/v1/post/1/comments?order=score", ``, CodeInvalidParam},
+		{http.MethodGet, "/api/v1/post/1/comments?page=0", ``, CodeInvalidParam},
+		{http.MethodGet, "/api/v1/post/1/comments?size=0", ``, CodeInvalidParam},
+		{http.MethodGet, "/api/v1/post/1/comments?size=1000", ``, CodeInvalidParam},
+		{http.MethodPost, "/api/v1/post/abc/comments", `{"content": "c"}`, CodeInvalidParam},
+		{http.MethodPost, "/api/v1/post/1/comments", `{"parent_id": "1"}`, CodeInvalidParam},
+		{http.MethodPost, "/api/v1/post/1/comments", `{"parent_id": "1", "content": "c"}`, CodeNeedLogin},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, bytes.NewReader([]byte(tt.body)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		res := new(ResponseData)
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("json.Unmarshal w.Body failed, err:%v\n", err)
+		}
+		assert.Equal(t, tt.code, res.Code, "%s %s", tt.method, tt.url)
+	}
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"bluebell/dao/mysql"
 	"bluebell/logic"
 	"bluebell/models"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -54,6 +56,10 @@ func GetPostDetailHandler(c *gin.Context) {
 	data, err := logic.GetPostById(pid)
 	if err != nil {
 		zap.L().Error("logic.GetPostById(pid) failed", zap.Error(err))
+		if errors.Is(err, mysql.ErrorPostNotExist) {
+			ResponseError(c, CodePostNotExist)
+			return
+		}
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -61,6 +67,97 @@ func GetPostDetailHandler(c *gin.Context) {
 	ResponseSuccess(c, data)
 }
 
+// UpdatePostHandler 编辑帖子的处理函数
+func UpdatePostHandler(c *gin.Context) {
+	// 1. 获取参数及参数的校验
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("update post with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamUpdatePost)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("update post with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	// 2. 编辑帖子
+	if err := logic.UpdatePost(userID, pid, p); err != nil {
+		zap.L().Error("logic.UpdatePost failed", zap.Int64("pid", pid), zap.Error(err))
+		responsePostError(c, err)
+		return
+	}
+	// 3. 返回响应
+	ResponseSuccess(c, nil)
+}
+
+// DeletePostHandler 删除帖子的处理函数
+func DeletePostHandler(c *gin.Context) {
+	// 1. 获取参数
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("delete post with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	// 2. 删除帖子
+	if err := logic.DeletePost(userID, pid); err != nil {
+		zap.L().Error("logic.DeletePost failed", zap.Int64("pid", pid), zap.Error(err))
+		responsePostError(c, err)
+		return
+	}
+	// 3. 返回响应
+	ResponseSuccess(c, nil)
+}
+
+// GetPostHistoryHandler 查询帖子编辑历史的处理函数
+func GetPostHistoryHandler(c *gin.Context) {
+	// 1. 获取参数
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("get post history with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	// 2. 查询历史记录
+	data, err := logic.GetPostHistory(userID, pid)
+	if err != nil {
+		zap.L().Error("logic.GetPostHistory failed", zap.Int64("pid", pid), zap.Error(err))
+		responsePostError(c, err)
+		return
+	}
+	// 3. 返回响应
+	ResponseSuccess(c, data)
+}
+
+// responsePostError 把编辑、删除帖子及查询历史时的错误转换成响应
+func responsePostError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mysql.ErrorPostNotExist):
+		ResponseError(c, CodePostNotExist)
+	case errors.Is(err, logic.ErrorNoPermission):
+		ResponseError(c, CodeNoPermission)
+	default:
+		ResponseError(c, CodeServerBusy)
+	}
+}
+
 // GetPostListHandler 获取帖子列表的处理函数
 func GetPostListHandler(c *gin.Context) {
 	// 获取分页参数
@@ -82,7 +179,7 @@ func GetPostListHandler(c *gin.Context) {
 // @Tags 帖子相关接口(api分组展示使用的)
 // @Accept application/json
 // @Produce application/json
-// @Param Authorization header string true "Bearer JWT"
+// @Param Authorization header string false "Bearer JWT"
 // @Param object query models.ParamPostList false "查询参数"
 // @Security ApiKeyAuth
 // @Success 200 {object} _ResponsePostList
@@ -102,8 +199,10 @@ func GetPostListHandler2(c *gin.Context) {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
+	// 登录用户可以看到自己的投票，未登录时userID为0
+	userID, _ := getCurrentUserID(c)
 	// 获得帖子列表
-	data, err := logic.GetPostListNew(p) // 更新：合二为一
+	data, err := logic.GetPostListNew(userID, p) // 更新：合二为一
 	// 获取数据
 	if err != nil {
 		zap.L().Error("logic.GetPostList() failed", zap.Error(err))
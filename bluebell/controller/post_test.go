@@ -43,3 +43,39 @@ func TestCreatePostHandler(t *testing.T) {
 	}
 	assert.Equal(t, res.Code, CodeNeedLogin)
 }
+
+func TestUpdateDeletePostHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	url := "/api/v1/post/:id"
+	r.PUT(url, UpdatePostHandler)
+	r.DELETE(url, DeletePostHandler)
+	r.GET(url+"/history", GetPostHistoryHandler)
+
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		code   ResCode
+	}{
+		{http.MethodPut, "/api/v1/post/abc", `{"title": "t", "content": "c"}`, CodeInvalidParam},
+		{http.MethodPut, "/api/v1/post/1", `{"title": "t"}`, CodeInvalidParam},
+		{http.MethodPut, "/api/v1/post/1", `{"title": "t", "content": "c"}`, CodeNeedLogin},
+		{http.MethodDelete, "/api/v1/post/abc", ``, CodeInvalidParam},
+		{http.MethodDelete, "/api/v1/post/1", ``, CodeNeedLogin},
+		{http.MethodGet, "/api/v1/post/abc/history", ``, CodeInvalidParam},
+		{http.MethodGet, "/api/v1/post/1/history", ``, CodeNeedLogin},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, bytes.NewReader([]byte(tt.body)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		res := new(ResponseData)
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("json.Unmarshal w.Body failed, err:%v\n", err)
+		}
+		assert.Equal(t, tt.code, res.Code, "%s %s", tt.method, tt.url)
+	}
+}
@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// commentFields 查询评论时的字段，回复数用子查询统计，第一个参数是正常状态的评论状态值
+const commentFields = `c.comment_id, c.post_id, c.parent_id, c.author_id, c.content, c.votes, c.status, c.create_time,
+	(select count(1) from comment r where r.parent_id = c.comment_id and r.status = ?) as reply_num`
+
+// CreateComment 创建评论
+func CreateComment(c *models.Comment) (err error) {
+	sqlStr := `insert into comment(
+	comment_id, post_id, parent_id, author_id, content)
+	values (?, ?, ?, ?, ?)
+	`
+	_, err = db.Exec(sqlStr, c.ID, c.PostID, c.ParentID, c.AuthorID, c.Content)
+	return
+}
+
+// GetCommentById 根据id查询单条评论
+func GetCommentById(cid int64) (comment *models.Comment, err error) {
+	comment = new(models.Comment)
+	sqlStr := `select ` + commentFields + `
+	from comment c
+	where c.comment_id = ? and c.status = ?
+	`
+	err = db.Get(comment, sqlStr, models.CommentStatusNormal, cid, models.CommentStatusNormal)
+	if err == sql.ErrNoRows {
+		err = ErrorCommentNotExist
+	}
+	return
+}
+
+// GetCommentList 分页查询帖子下某条评论的回复，ParentID为0时查询直接评论帖子的评论
+func GetCommentList(p *models.ParamCommentList) (comments []*models.Comment, err error) {
+	orderBy := "c.create_time desc"
+	if p.Order == models.OrderVotes {
+		orderBy = "c.votes desc, c.create_time desc"
+	}
+	sqlStr := `select ` + commentFields + `
+	from comment c
+	where c.post_id = ? and c.parent_id = ? and c.status = ?
+	order by ` + orderBy + `
+	limit ?,?
+	`
+	comments = make([]*models.Comment, 0, p.Size)
+	err = db.Select(&comments, sqlStr, models.CommentStatusNormal,
+		p.PostID, p.ParentID, models.CommentStatusNormal, (p.Page-1)*p.Size, p.Size)
+	return
+}
+
+// VoteForComment 记录用户对评论的投票并在同一个事务中更新评论的净票数
+// direction为0时取消投票，与之前的投票相同时返回ErrorVoteRepeated
+func VoteForComment(cid, userID int64, direction int8) (err error) {
+	tx, err := db.Beginx() // 投票记录和净票数要么都更新要么都不更新
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var ov int8 // 之前的投票，没投过票时为0
+	sqlStr := `select direction from comment_vote where comment_id = ? and user_id = ? for update`
+	err = tx.Get(&ov, sqlStr, cid, userID)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	if ov == direction {
+		err = ErrorVoteRepeated
+		return err
+	}
+
+	if direction == 0 {
+		sqlStr = `delete from comment_vote where comment_id = ? and user_id = ?`
+		_, err = tx.Exec(sqlStr, cid, userID)
+	} else {
+		sqlStr = `insert into comment_vote(comment_id, user_id, direction) values (?, ?, ?)
+		on duplicate key update direction = values(direction)`
+		_, err = tx.Exec(sqlStr, cid, userID, direction)
+	}
+	if err != nil {
+		return err
+	}
+
+	sqlStr = `update comment set votes = votes + ? where comment_id = ? and status = ?`
+	ret, err := tx.Exec(sqlStr, int64(direction)-int64(ov), cid, models.CommentStatusNormal)
+	if err != nil {
+		return err
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 { // 期间被删除了
+		err = ErrorCommentNotExist
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPostCommentNum 查询帖子的评论数(包括回复)
+func GetPostCommentNum(pid int64) (num int64, err error) {
+	sqlStr := `select count(1) from comment where post_id = ? and status = ?`
+	err = db.Get(&num, sqlStr, pid, models.CommentStatusNormal)
+	return
+}
+
+// GetPostCommentNums 批量查询帖子的评论数，返回帖子id到评论数的映射
+func GetPostCommentNums(ids []string) (nums map[int64]int64, err error) {
+	sqlStr := `select post_id, count(1) as num
+	from comment
+	where post_id in (?) and status = ?
+	group by post_id
+	`
+	query, args, err := sqlx.In(sqlStr, ids, models.CommentStatusNormal)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	var rows []struct {
+		PostID int64 `db:"post_id"`
+		Num    int64 `db:"num"`
+	}
+	if err = db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+	nums = make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		nums[row.PostID] = row.Num
+	}
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"bluebell/models"
 	"database/sql"
 
+	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
@@ -33,3 +34,22 @@ func GetCommunityDetailByID(id int64) (community *models.CommunityDetail, err er
 	}
 	return community, err
 }
+
+// GetCommunitiesByIDs 根据id列表批量查询社区详情
+func GetCommunitiesByIDs(ids []int64) (communities []*models.CommunityDetail, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sqlStr := `select 
+			community_id, community_name, introduction, create_time
+			from community 
+			where community_id in (?)
+	`
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	err = db.Select(&communities, query, args...)
+	return
+}
@@ -7,4 +7,7 @@ var (
 	ErrorUserNotExist    = errors.New("用户不存在")
 	ErrorInvalidPassword = errors.New("用户名或密码错误")
 	ErrorInvalidID       = errors.New("无效的ID")
+	ErrorPostNotExist    = errors.New("帖子不存在")
+	ErrorCommentNotExist = errors.New("评论不存在")
+	ErrorVoteRepeated    = errors.New("不允许重复投票")
 )
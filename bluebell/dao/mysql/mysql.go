@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"bluebell/setting"
+	"database/sql"
 	"fmt"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -23,6 +24,12 @@ func Init(cfg *setting.MySQLConfig) (err error) {
 	return
 }
 
+// InitWithDB 使用已有的连接初始化，driverName决定SQL语句中占位符的格式
+// 主要用于在测试中替换成其他的数据库驱动
+func InitWithDB(sqlDB *sql.DB, driverName string) {
+	db = sqlx.NewDb(sqlDB, driverName)
+}
+
 // Close 关闭MySQL连接
 func Close() {
 	_ = db.Close()
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"bluebell/models"
+	"database/sql"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -21,11 +22,102 @@ func CreatePost(p *models.Post) (err error) {
 func GetPostById(pid int64) (post *models.Post, err error) {
 	post = new(models.Post)
 	sqlStr := `select
-	post_id, title, content, author_id, community_id, create_time
+	post_id, title, content, author_id, community_id, status, create_time
 	from post
+	where post_id = ? and status = ?
+	`
+	err = db.Get(post, sqlStr, pid, models.PostStatusNormal)
+	if err == sql.ErrNoRows {
+		err = ErrorPostNotExist
+	}
+	return
+}
+
+// UpdatePost 编辑帖子，修改前的标题和内容保存到post_history表
+func UpdatePost(old *models.Post, editorID int64, p *models.ParamUpdatePost) (err error) {
+	tx, err := db.Beginx() // 历史记录和修改要么都成功要么都失败
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertPostHistory(tx, old, editorID, models.PostActionEdit); err != nil {
+		return err
+	}
+
+	sqlStr := `update post set title = ?, content = ?
+	where post_id = ? and status = ?
+	`
+	ret, err := tx.Exec(sqlStr, p.Title, p.Content, old.ID, models.PostStatusNormal)
+	if err != nil {
+		return err
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 { // 期间被删除了
+		err = ErrorPostNotExist
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeletePost 软删除帖子，只修改帖子状态，删除前的标题和内容保存到post_history表
+func DeletePost(old *models.Post, editorID int64) (err error) {
+	tx, err := db.Beginx() // 历史记录和删除要么都成功要么都失败
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertPostHistory(tx, old, editorID, models.PostActionDelete); err != nil {
+		return err
+	}
+
+	sqlStr := `update post set status = ? where post_id = ? and status = ?`
+	ret, err := tx.Exec(sqlStr, models.PostStatusDeleted, old.ID, models.PostStatusNormal)
+	if err != nil {
+		return err
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		err = ErrorPostNotExist
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertPostHistory 在事务中保存帖子操作前的标题和内容
+func insertPostHistory(tx *sqlx.Tx, old *models.Post, editorID int64, action int8) error {
+	sqlStr := `insert into post_history(
+	post_id, editor_id, action, title, content)
+	values (?, ?, ?, ?, ?)
+	`
+	_, err := tx.Exec(sqlStr, old.ID, editorID, action, old.Title, old.Content)
+	return err
+}
+
+// GetPostHistory 查询帖子的历史记录，按操作时间从新到旧排列
+func GetPostHistory(pid int64) (history []*models.PostHistory, err error) {
+	sqlStr := `select post_id, editor_id, action, title, content, create_time
+	from post_history
 	where post_id = ?
+	order by id desc
 	`
-	err = db.Get(post, sqlStr, pid)
+	history = make([]*models.PostHistory, 0)
+	err = db.Select(&history, sqlStr, pid)
 	return
 }
 
@@ -34,12 +126,13 @@ func GetPostList(page, size int64) (posts []*models.Post, err error) {
 	sqlStr := `select 
 	post_id, title, content, author_id, community_id, create_time
 	from post
+	where status = ?
 	ORDER BY create_time
 	DESC
 	limit ?,?
 	`
 	posts = make([]*models.Post, 0, 2) // 不要写成make([]*models.Post, 2)
-	err = db.Select(&posts, sqlStr, (page-1)*size, size)
+	err = db.Select(&posts, sqlStr, models.PostStatusNormal, (page-1)*size, size)
 	return
 }
 
@@ -48,11 +141,11 @@ func GetPostListByIDs(ids []string) (postList []*models.Post, err error) {
 	// FIND_IN_SET 是MySQL函数，用于查询某字符串是否出现在第二个参数字符串列表中，第二个参数用逗号分隔
 	sqlStr := `select post_id, title, content, author_id, community_id, create_time
 	from post
-	where post_id in (?)
+	where post_id in (?) and status = ?
 	order by FIND_IN_SET(post_id, ?)
 	`
 	// https: //www.liwenzhou.com/posts/Go/sqlx/
-	query, args, err := sqlx.In(sqlStr, ids, strings.Join(ids, ","))
+	query, args, err := sqlx.In(sqlStr, ids, models.PostStatusNormal, strings.Join(ids, ","))
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,8 @@ import (
 	"crypto/md5"
 	"database/sql"
 	"encoding/hex"
+
+	"github.com/jmoiron/sqlx"
 )
 
 // 把每一步数据库操作封装成函数
@@ -68,3 +70,18 @@ func GetUserById(uid int64) (user *models.User, err error) {
 	err = db.Get(user, sqlStr, uid)
 	return
 }
+
+// GetUsersByIDs 根据id列表批量查询用户信息
+func GetUsersByIDs(uids []int64) (users []*models.User, err error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	sqlStr := `select user_id, username from user where user_id in (?)`
+	query, args, err := sqlx.In(sqlStr, uids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	err = db.Select(&users, query, args...)
+	return
+}
@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const voteInsertBatchSize = 500 // 每条insert语句保存的投票数量
+
+// SaveVoteResult 保存投票结算后帖子的赞成票数、反对票数、最终分数及每个用户的投票(用户id到投票值)
+// 已结算的帖子不会被更新，重复结算是安全的
+func SaveVoteResult(pid, up, down int64, score float64, votes map[int64]int8) (err error) {
+	tx, err := db.Beginx() // 投票结果和用户投票要么都保存要么都不保存
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	sqlStr := `update post set up_votes = ?, down_votes = ?, score = ?, settled = 1
+	where post_id = ? and settled = 0`
+	ret, err := tx.Exec(sqlStr, up, down, score, pid)
+	if err != nil {
+		return err
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 { // 已经结算过了
+		return tx.Commit()
+	}
+
+	values := make([]string, 0, voteInsertBatchSize)
+	args := make([]interface{}, 0, voteInsertBatchSize*3)
+	flush := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		sqlStr := `insert into post_vote(post_id, user_id, direction) values ` + strings.Join(values, ",")
+		_, err := tx.Exec(sqlStr, args...)
+		values, args = values[:0], args[:0]
+		return err
+	}
+	for uid, direction := range votes {
+		values = append(values, "(?, ?, ?)")
+		args = append(args, pid, uid, direction)
+		if len(values) == voteInsertBatchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSettledVoteData 批量查询已结算帖子的投票数据，返回帖子id到投票数据的映射
+// userID不为0时包括该用户结算时的投票
+func GetSettledVoteData(ids []string, userID int64) (data map[string]*models.PostVoteData, err error) {
+	sqlStr := `select post_id, up_votes, down_votes from post where post_id in (?)`
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	var rows []struct {
+		PostID    string `db:"post_id"`
+		UpVotes   int64  `db:"up_votes"`
+		DownVotes int64  `db:"down_votes"`
+	}
+	if err = db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+	data = make(map[string]*models.PostVoteData, len(rows))
+	for _, row := range rows {
+		data[row.PostID] = &models.PostVoteData{
+			VoteNum:     row.UpVotes,
+			DownVoteNum: row.DownVotes,
+			NetVoteNum:  row.UpVotes - row.DownVotes,
+		}
+	}
+	if userID == 0 {
+		return data, nil
+	}
+
+	// 用户没有投过票时为0
+	sqlStr = `select post_id, direction from post_vote where user_id = ? and post_id in (?)`
+	query, args, err = sqlx.In(sqlStr, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	var votes []struct {
+		PostID    string `db:"post_id"`
+		Direction int8   `db:"direction"`
+	}
+	if err = db.Select(&votes, query, args...); err != nil {
+		return nil, err
+	}
+	myVotes := make(map[string]int8, len(votes))
+	for _, vote := range votes {
+		myVotes[vote.PostID] = vote.Direction
+	}
+	for id, v := range data {
+		myVote := myVotes[id]
+		v.MyVote = &myVote
+	}
+	return data, nil
+}
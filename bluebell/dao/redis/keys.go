@@ -1,18 +1,37 @@
 package redis
 
+import "bluebell/models"
+
 // redis key
 
 // redis key注意使用命名空间的方式,方便查询和拆分
 
 const (
-	Prefix             = "bluebell:"   // 项目key前缀
-	KeyPostTimeZSet    = "post:time"   // zset;贴子及发帖时间
-	KeyPostScoreZSet   = "post:score"  // zset;贴子及投票的分数
-	KeyPostVotedZSetPF = "post:voted:" // zset;记录用户及投票类型;参数是post id
+	Prefix               = "bluebell:"           // 项目key前缀
+	KeyPostTimeZSet      = "post:time"           // zset;贴子及发帖时间
+	KeyPostScoreZSet     = "post:score"          // zset;贴子及投票的分数
+	KeyPostVotedZSetPF   = "post:voted:"         // zset;记录用户及投票类型;参数是post id
+	KeyPostSettledZSet   = "post:settled"        // zset;投票已结算(停止投票)的帖子及发帖时间
+	KeyPostSettlingSet   = "post:settling"       // set;已停止投票但结果还没有保存完的帖子
+	KeyPostSettledBefore = "post:settled:before" // string;发帖时间早于该值的帖子都已结算
+	KeyPostRankZSetPF    = "post:rank:"          // zset;帖子及按排名算法计算的分数;参数是算法名称
 
 	KeyCommunitySetPF = "community:" // set;保存每个分区下帖子的id
+
+	KeySettleLock = "lock:settle" // string;投票结算锁
 )
 
+// getOrderKey 根据排序依据获取保存帖子排序分数的key
+func getOrderKey(order string) string {
+	switch order {
+	case models.OrderTime:
+		return getRedisKey(KeyPostTimeZSet)
+	case models.OrderScore:
+		return getRedisKey(KeyPostScoreZSet)
+	}
+	return getRedisKey(KeyPostRankZSetPF + order)
+}
+
 // 给redis key加上前缀
 func getRedisKey(key string) string {
 	return Prefix + key
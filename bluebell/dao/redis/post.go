@@ -18,16 +18,14 @@ func getIDsFormKey(key string, page, size int64) ([]string, error) {
 func GetPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 	// 从redis获取id
 	// 1. 根据用户请求中携带的order参数确定要查询的redis key
-	key := getRedisKey(KeyPostTimeZSet)
-	if p.Order == models.OrderScore {
-		key = getRedisKey(KeyPostScoreZSet)
-	}
+	key := getOrderKey(p.Order)
 	// 2. 确定查询的索引起始点
 	return getIDsFormKey(key, p.Page, p.Size)
 }
 
-// GetPostVoteData 根据ids查询每篇帖子的投赞成票的数据
-func GetPostVoteData(ids []string) (data []int64, err error) {
+// GetPostVoteData 根据ids查询每篇帖子的赞成票、反对票数，userID不为空时同时查询该用户的投票
+// 投票已结算的帖子在redis中没有投票记录，settled中对应的值为true，需要去MySQL查询
+func GetPostVoteData(ids []string, userID string) (data []*models.PostVoteData, settled []bool, err error) {
 	//data = make([]int64, 0, len(ids))
 	//for _, id := range ids {
 	//	key := getRedisKey(KeyPostVotedZSetPF + id)
@@ -36,30 +34,56 @@ func GetPostVoteData(ids []string) (data []int64, err error) {
 	//	data = append(data, v)
 	//}
 	// 使用pipeline一次发送多条命令,减少RTT
+	type voteCmds struct {
+		up, down                 *redis.IntCmd
+		created, settled, myVote *redis.FloatCmd
+		settling                 *redis.BoolCmd
+	}
+	cmds := make([]voteCmds, len(ids))
 	pipeline := client.Pipeline()
-	for _, id := range ids {
+	// 发帖时间早于它的帖子已从已结算zset中删除
+	settledBefore := pipeline.Get(getRedisKey(KeyPostSettledBefore))
+	for i, id := range ids {
 		key := getRedisKey(KeyPostVotedZSetPF + id)
-		pipeline.ZCount(key, "1", "1")
+		cmds[i].up = pipeline.ZCount(key, "1", "1")
+		cmds[i].down = pipeline.ZCount(key, "-1", "-1")
+		cmds[i].created = pipeline.ZScore(getRedisKey(KeyPostTimeZSet), id)
+		cmds[i].settled = pipeline.ZScore(getRedisKey(KeyPostSettledZSet), id)
+		cmds[i].settling = pipeline.SIsMember(getRedisKey(KeyPostSettlingSet), id)
+		if userID != "" {
+			cmds[i].myVote = pipeline.ZScore(key, userID)
+		}
 	}
-	cmders, err := pipeline.Exec()
-	if err != nil {
-		return nil, err
+	// 未结算的帖子及用户没有投过票时ZScore返回redis.Nil
+	if _, err = pipeline.Exec(); err != nil && err != redis.Nil {
+		return nil, nil, err
 	}
-	data = make([]int64, 0, len(cmders))
-	for _, cmder := range cmders {
-		v := cmder.(*redis.IntCmd).Val()
+	before, _ := settledBefore.Int64()
+	data = make([]*models.PostVoteData, 0, len(ids))
+	settled = make([]bool, 0, len(ids))
+	for _, c := range cmds {
+		v := &models.PostVoteData{
+			VoteNum:     c.up.Val(),
+			DownVoteNum: c.down.Val(),
+		}
+		v.NetVoteNum = v.VoteNum - v.DownVoteNum
+		// 结果还没有保存到MySQL时投票记录仍在redis中，从redis读取
+		isSettled := (c.settled.Err() == nil || c.created.Err() == nil && int64(c.created.Val()) < before) &&
+			!c.settling.Val()
+		if c.myVote != nil && !isSettled {
+			myVote := int8(c.myVote.Val())
+			v.MyVote = &myVote
+		}
 		data = append(data, v)
+		settled = append(settled, isSettled)
 	}
-	return
+	return data, settled, nil
 }
 
 // GetCommunityPostIDsInOrder 按社区查询ids
 func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 
-	orderKey := getRedisKey(KeyPostTimeZSet)
-	if p.Order == models.OrderScore {
-		orderKey = getRedisKey(KeyPostScoreZSet)
-	}
+	orderKey := getOrderKey(p.Order)
 
 	// 使用 zinterstore 把分区的帖子set与帖子分数的 zset 生成一个新的zset
 	// 针对新的zset 按之前的逻辑取数据
@@ -84,3 +108,57 @@ func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 	// 存在的话就直接根据key查询ids
 	return getIDsFormKey(key, p.Page, p.Size)
 }
+
+// GetUnsettledPostIDs 按发帖时间从早到晚分批查询还在投票期限内的帖子id
+func GetUnsettledPostIDs(offset, count int64) ([]string, error) {
+	deadline := strconv.FormatInt(time.Now().Unix()-oneWeekInSeconds, 10)
+	return getPostIDsByTime("("+deadline, "+inf", offset, count)
+}
+
+// GetExpiredPostIDs 按发帖时间从早到晚分批查询投票已到期的帖子id
+func GetExpiredPostIDs(offset, count int64) ([]string, error) {
+	deadline := strconv.FormatInt(time.Now().Unix()-oneWeekInSeconds, 10)
+	return getPostIDsByTime("-inf", deadline, offset, count)
+}
+
+// getPostIDsByTime 按发帖时间从早到晚分批查询发帖时间在[min, max]之间的帖子id
+func getPostIDsByTime(min, max string, offset, count int64) ([]string, error) {
+	return client.ZRangeByScore(getRedisKey(KeyPostTimeZSet), redis.ZRangeBy{
+		Min:    min,
+		Max:    max,
+		Offset: offset,
+		Count:  count,
+	}).Result()
+}
+
+// GetPostCreateTimes 根据ids查询每篇帖子的发帖时间，不存在的帖子为0
+func GetPostCreateTimes(ids []string) (times []int64, err error) {
+	pipeline := client.Pipeline()
+	for _, id := range ids {
+		pipeline.ZScore(getRedisKey(KeyPostTimeZSet), id)
+	}
+	cmders, err := pipeline.Exec()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	times = make([]int64, 0, len(cmders))
+	for _, cmder := range cmders {
+		times = append(times, int64(cmder.(*redis.FloatCmd).Val()))
+	}
+	return times, nil
+}
+
+// SetPostRankScores 保存帖子按排名算法计算的分数
+func SetPostRankScores(order string, ids []string, scores []float64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(ids))
+	for i, id := range ids {
+		members = append(members, redis.Z{
+			Score:  scores[i],
+			Member: id,
+		})
+	}
+	return client.ZAdd(getOrderKey(order), members...).Err()
+}
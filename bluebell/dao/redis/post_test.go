@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPostVoteData(t *testing.T) {
+	setupMiniRedis(t)
+	for _, id := range []int64{1, 2} {
+		if err := CreatePost(id, 1); err != nil {
+			t.Fatalf("CreatePost failed, err:%v\n", err)
+		}
+	}
+	assert.Nil(t, VoteForPost("100", "1", 1))
+	assert.Nil(t, VoteForPost("101", "1", 1))
+	assert.Nil(t, VoteForPost("102", "1", -1))
+	assert.Nil(t, VoteForPost("100", "2", -1))
+	_, _, _, err := CloseVote("2")
+	assert.Nil(t, err)
+
+	// 结果还没有保存完时仍然从redis读取
+	data, settled, err := GetPostVoteData([]string{"1", "2"}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false}, settled)
+	assert.Equal(t, int64(1), data[1].DownVoteNum)
+
+	assert.Nil(t, FinishSettlement("2"))
+
+	// 未登录
+	data, settled, err = GetPostVoteData([]string{"1", "2"}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, true}, settled)
+	assert.Equal(t, int64(2), data[0].VoteNum)
+	assert.Equal(t, int64(1), data[0].DownVoteNum)
+	assert.Equal(t, int64(1), data[0].NetVoteNum)
+	assert.Nil(t, data[0].MyVote)
+
+	// 登录用户能看到自己的投票，没投过票为0
+	data, _, err = GetPostVoteData([]string{"1", "2"}, "102")
+	assert.Nil(t, err)
+	if assert.NotNil(t, data[0].MyVote) {
+		assert.Equal(t, int8(-1), *data[0].MyVote)
+	}
+	assert.Nil(t, data[1].MyVote) // 已结算
+
+	data, _, err = GetPostVoteData([]string{"1"}, "103")
+	assert.Nil(t, err)
+	if assert.NotNil(t, data[0].MyVote) {
+		assert.Equal(t, int8(0), *data[0].MyVote)
+	}
+}
+
+func TestGetUnsettledPostIDs(t *testing.T) {
+	setupMiniRedis(t)
+	now := time.Now().Unix()
+	for id, created := range map[string]int64{
+		"1": now - oneWeekInSeconds - 10, // 投票已到期
+		"2": now - 100,
+		"3": now,
+	} {
+		client.ZAdd(getRedisKey(KeyPostTimeZSet), redis.Z{Score: float64(created), Member: id})
+	}
+
+	ids, err := GetUnsettledPostIDs(0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "3"}, ids)
+
+	ids, err = GetUnsettledPostIDs(1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3"}, ids)
+}
@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// 投票结算：帖子发布一周后不再允许投票，
+// 此时把赞成票数、反对票数及最终分数保存到MySQL，并删除 KeyPostVotedZSetPF
+// 结算分三步：CloseVote停止投票并读取结果 -> 保存到MySQL -> FinishSettlement删除投票记录，
+// 中途失败时帖子留在 KeyPostSettlingSet 中，下次结算时重试
+
+// settleRetention 已结算帖子在 KeyPostSettledZSet 中保留的时间，
+// 发帖时间比投票截止时间还早这么久的帖子视为不会再出现，KeyPostSettledBefore 最多推进到这里
+const settleRetention = oneWeekInSeconds
+
+// getSettledBefore 查询 KeyPostSettledBefore，发帖时间早于该值的帖子都已结算，没有时返回0
+func getSettledBefore() (int64, error) {
+	before, err := client.Get(getRedisKey(KeyPostSettledBefore)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return before, err
+}
+
+// GetUnsettledExpiredPostIDs 查询投票已到期但还没有结算的帖子，按发帖时间从早到晚最多返回count个
+// 从 KeyPostSettledBefore 开始(第一次结算时从0开始)扫描已到期的帖子并跳过已结算的帖子，
+// 其他实例因时钟偏差晚写入的较早的帖子也能被扫描到
+func GetUnsettledExpiredPostIDs(count int64) (ids []string, err error) {
+	before, err := getSettledBefore()
+	if err != nil {
+		return nil, err
+	}
+	minScore := strconv.FormatInt(before, 10)
+	maxScore := strconv.FormatInt(time.Now().Unix()-oneWeekInSeconds, 10)
+
+	for offset := int64(0); int64(len(ids)) < count; {
+		candidates, err := client.ZRangeByScore(getRedisKey(KeyPostTimeZSet), redis.ZRangeBy{
+			Min:    minScore,
+			Max:    maxScore,
+			Offset: offset,
+			Count:  count,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		pipeline := client.Pipeline()
+		for _, id := range candidates {
+			pipeline.ZScore(getRedisKey(KeyPostSettledZSet), id)
+		}
+		cmders, err := pipeline.Exec()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for i, cmder := range cmders {
+			if cmder.Err() == redis.Nil && int64(len(ids)) < count {
+				ids = append(ids, candidates[i])
+			}
+		}
+		// 已经没有更多帖子了
+		if int64(len(candidates)) < count {
+			break
+		}
+		offset += int64(len(candidates))
+	}
+	return ids, nil
+}
+
+// TrimSettledPosts 推进 KeyPostSettledBefore 到最早的未结算帖子(最多到投票截止时间之前settleRetention秒)，
+// 并从 KeyPostSettledZSet 中删除发帖时间早于它的帖子，避免已结算zset无限增长
+func TrimSettledPosts() error {
+	before := time.Now().Unix() - oneWeekInSeconds - settleRetention
+	ids, err := GetUnsettledExpiredPostIDs(1)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		first, err := client.ZScore(getRedisKey(KeyPostTimeZSet), ids[0]).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if int64(first) < before {
+			before = int64(first)
+		}
+	}
+	old, err := getSettledBefore()
+	if err != nil || before <= old {
+		return err
+	}
+	pipeline := client.TxPipeline()
+	pipeline.Set(getRedisKey(KeyPostSettledBefore), before, 0)
+	pipeline.ZRemRangeByScore(getRedisKey(KeyPostSettledZSet), "-inf", "("+strconv.FormatInt(before, 10))
+	_, err = pipeline.Exec()
+	return err
+}
+
+// closeVoteScript 停止帖子的投票并读取最终的投票结果
+// 帖子加入已结算zset后voteScript不再接受投票，读取和标记在同一个脚本中完成，中间不会插入新的投票；
+// 投票记录保留到 FinishSettlement，结算失败重试时返回相同的结果
+// KEYS[1]: 帖子时间zset  KEYS[2]: 已结算帖子zset  KEYS[3]: 正在结算的帖子set  KEYS[4]: 帖子投票记录zset  KEYS[5]: 帖子分数zset
+// ARGV[1]: 帖子id
+// 返回值: {赞成票数, 反对票数, 分数}，帖子不存在时返回nil
+var closeVoteScript = redis.NewScript(`
+local postTime = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not postTime then
+	return false
+end
+if not redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	redis.call('ZADD', KEYS[2], postTime, ARGV[1])
+	redis.call('SADD', KEYS[3], ARGV[1])
+end
+local up = redis.call('ZCOUNT', KEYS[4], 1, 1)
+local down = redis.call('ZCOUNT', KEYS[4], -1, -1)
+local score = redis.call('ZSCORE', KEYS[5], ARGV[1]) or '0'
+return {up, down, score}
+`)
+
+// CloseVote 停止帖子的投票，返回帖子最终的赞成票数、反对票数及分数
+// 帖子不存在时返回redis.Nil
+func CloseVote(postID string) (up, down int64, score float64, err error) {
+	keys := []string{
+		getRedisKey(KeyPostTimeZSet),
+		getRedisKey(KeyPostSettledZSet),
+		getRedisKey(KeyPostSettlingSet),
+		getRedisKey(KeyPostVotedZSetPF + postID),
+		getRedisKey(KeyPostScoreZSet),
+	}
+	ret, err := closeVoteScript.Run(client, keys, postID).Result()
+	if err != nil {
+		return
+	}
+	values, ok := ret.([]interface{})
+	if !ok || len(values) != 3 {
+		return 0, 0, 0, fmt.Errorf("unexpected result of closeVoteScript: %v", ret)
+	}
+	up, _ = values[0].(int64)
+	down, _ = values[1].(int64)
+	s, _ := values[2].(string)
+	score, err = strconv.ParseFloat(s, 64)
+	return
+}
+
+// GetPostVotes 查询帖子每个用户的投票，返回用户id到投票值的映射
+// 在CloseVote之后调用，此时投票记录不会再变化
+func GetPostVotes(postID string) (votes map[string]int8, err error) {
+	members, err := client.ZRangeWithScores(getRedisKey(KeyPostVotedZSetPF+postID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	votes = make(map[string]int8, len(members))
+	for _, m := range members {
+		votes[m.Member.(string)] = int8(m.Score)
+	}
+	return votes, nil
+}
+
+// GetSettlingPostIDs 查询已经停止投票、但结果还没有保存完的帖子
+func GetSettlingPostIDs() ([]string, error) {
+	return client.SMembers(getRedisKey(KeyPostSettlingSet)).Result()
+}
+
+// FinishSettlement 投票结果保存到MySQL之后删除帖子的投票记录
+func FinishSettlement(postID string) error {
+	pipeline := client.TxPipeline()
+	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + postID))
+	pipeline.SRem(getRedisKey(KeyPostSettlingSet), postID)
+	_, err := pipeline.Exec()
+	return err
+}
+
+// unlockScript 只释放自己持有的锁，避免锁过期后删掉别人的锁
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireSettleLock 获取结算锁(SET NX PX)，多个实例同时运行定时任务时只有一个能够结算
+// 获取成功时返回释放锁要用的token
+func AcquireSettleLock(ttl time.Duration) (token string, ok bool, err error) {
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return "", false, err
+	}
+	token = hex.EncodeToString(b)
+	ok, err = client.SetNX(getRedisKey(KeySettleLock), token, ttl).Result()
+	return
+}
+
+// ReleaseSettleLock 释放结算锁
+func ReleaseSettleLock(token string) error {
+	return unlockScript.Run(client, []string{getRedisKey(KeySettleLock)}, token).Err()
+}
@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseVote(t *testing.T) {
+	setupMiniRedis(t)
+	if err := CreatePost(1, 1); err != nil {
+		t.Fatalf("CreatePost failed, err:%v\n", err)
+	}
+	assert.Nil(t, VoteForPost("100", "1", 1))
+	assert.Nil(t, VoteForPost("101", "1", -1))
+	assert.Nil(t, VoteForPost("102", "1", 1))
+	want := client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val()
+
+	up, down, score, err := CloseVote("1")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(2), int64(1), want}, []interface{}{up, down, score})
+
+	// 停止投票后不再接受投票，重复执行返回相同的结果
+	assert.Equal(t, ErrVoteTimeExpire, VoteForPost("103", "1", 1))
+	up, down, score, err = CloseVote("1")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(2), int64(1), want}, []interface{}{up, down, score})
+
+	ids, err := GetSettlingPostIDs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, ids)
+
+	votes, err := GetPostVotes("1")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int8{"100": 1, "101": -1, "102": 1}, votes)
+
+	assert.Nil(t, FinishSettlement("1"))
+	ids, err = GetSettlingPostIDs()
+	assert.Nil(t, err)
+	assert.Empty(t, ids)
+	assert.Equal(t, int64(0), client.Exists(getRedisKey(KeyPostVotedZSetPF+"1")).Val())
+
+	// 不存在的帖子
+	_, _, _, err = CloseVote("2")
+	assert.Equal(t, Nil, err)
+}
+
+// addPost 按指定的发帖时间添加帖子
+func addPost(id string, created int64) {
+	client.ZAdd(getRedisKey(KeyPostTimeZSet), redis.Z{Score: float64(created), Member: id})
+}
+
+func TestGetUnsettledExpiredPostIDs(t *testing.T) {
+	setupMiniRedis(t)
+	expired := time.Now().Unix() - oneWeekInSeconds - 100
+	addPost("1", expired)
+	addPost("2", expired+10)
+	addPost("3", time.Now().Unix())
+	_, _, _, err := CloseVote("2")
+	assert.Nil(t, err)
+	assert.Nil(t, FinishSettlement("2"))
+
+	ids, err := GetUnsettledExpiredPostIDs(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, ids)
+
+	// 较新的帖子结算后才写入的较早的帖子也要结算
+	addPost("4", expired-10)
+	ids, err = GetUnsettledExpiredPostIDs(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4", "1"}, ids)
+
+	// 跳过已结算的帖子分页读取
+	ids, err = GetUnsettledExpiredPostIDs(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4"}, ids)
+}
+
+func TestTrimSettledPosts(t *testing.T) {
+	setupMiniRedis(t)
+	now := time.Now().Unix()
+	old := now - oneWeekInSeconds - settleRetention - 100
+	addPost("1", old)
+	addPost("2", old+10)
+	addPost("3", now-oneWeekInSeconds-100)
+	for _, id := range []string{"1", "2", "3"} {
+		_, _, _, err := CloseVote(id)
+		assert.Nil(t, err)
+		assert.Nil(t, FinishSettlement(id))
+	}
+
+	assert.Nil(t, TrimSettledPosts())
+	assert.Equal(t, []string{"3"}, client.ZRange(getRedisKey(KeyPostSettledZSet), 0, -1).Val())
+	// 删除后仍然视为已结算，不会再被扫描到
+	ids, err := GetUnsettledExpiredPostIDs(10)
+	assert.Nil(t, err)
+	assert.Empty(t, ids)
+	_, settled, err := GetPostVoteData([]string{"1", "2", "3"}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, true, true}, settled)
+	assert.Equal(t, ErrVoteTimeExpire, VoteForPost("100", "1", 1))
+
+	// 有未结算的帖子时只推进到该帖子
+	client.FlushAll()
+	addPost("1", old)
+	addPost("2", old+10)
+	_, _, _, err = CloseVote("1")
+	assert.Nil(t, err)
+	assert.Nil(t, FinishSettlement("1"))
+	assert.Nil(t, TrimSettledPosts())
+	before, err := getSettledBefore()
+	assert.Nil(t, err)
+	assert.Equal(t, old+10, before)
+	ids, err = GetUnsettledExpiredPostIDs(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2"}, ids)
+}
+
+func TestSettleLock(t *testing.T) {
+	setupMiniRedis(t)
+	token, ok, err := AcquireSettleLock(time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	other, ok, err := AcquireSettleLock(time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok, "lock acquired twice")
+
+	// 只能释放自己持有的锁
+	assert.Nil(t, ReleaseSettleLock(other))
+	assert.Equal(t, token, client.Get(getRedisKey(KeySettleLock)).Val())
+	assert.Nil(t, ReleaseSettleLock(token))
+	_, ok, err = AcquireSettleLock(time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
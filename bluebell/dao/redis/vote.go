@@ -1,8 +1,8 @@
 package redis
 
 import (
+	"bluebell/models"
 	"errors"
-	"math"
 	"strconv"
 	"time"
 
@@ -62,42 +62,68 @@ func CreatePost(postID, communityID int64) error {
 	return err
 }
 
-func VoteForPost(userID, postID string, value float64) error {
-	// 1. 判断投票限制
-	// 去redis取帖子发布时间
-	postTime := client.ZScore(getRedisKey(KeyPostTimeZSet), postID).Val()
-	if float64(time.Now().Unix())-postTime > oneWeekInSeconds {
-		return ErrVoteTimeExpire
+// DeletePost 把帖子从时间、分数及其他排名算法(rankOrders)的排行和所属社区中移除
+func DeletePost(postID, communityID int64, rankOrders ...string) error {
+	id := strconv.FormatInt(postID, 10)
+	cid := strconv.Itoa(int(communityID))
+	orders := append([]string{models.OrderTime, models.OrderScore}, rankOrders...)
+	pipeline := client.TxPipeline()
+	for _, order := range orders {
+		pipeline.ZRem(getOrderKey(order), id)
+		// 社区帖子列表的缓存key(见GetCommunityPostIDsInOrder)里也要移除
+		pipeline.ZRem(getOrderKey(order)+cid, id)
 	}
-	// 2和3需要放到一个pipeline事务中操作
+	pipeline.SRem(getRedisKey(KeyCommunitySetPF+cid), id)
+	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + id))
+	_, err := pipeline.Exec()
+	return err
+}
 
-	// 2. 更新贴子的分数
-	// 先查当前用户给当前帖子的投票记录
-	ov := client.ZScore(getRedisKey(KeyPostVotedZSetPF+postID), userID).Val()
+// voteScript 在一个Lua脚本中完成投票的检查和更新，避免先读后写时并发投票导致分数被重复修改
+// 已结算的帖子即使还在投票期限内(各服务器时钟不一致)也不允许投票
+// KEYS[1]: 帖子时间zset  KEYS[2]: 帖子分数zset  KEYS[3]: 帖子投票记录zset  KEYS[4]: 已结算帖子zset
+// ARGV[1]: 帖子id  ARGV[2]: 用户id  ARGV[3]: 投票值  ARGV[4]: 当前时间  ARGV[5]: 投票期限  ARGV[6]: 每一票的分数
+// 返回值: 0 成功  -1 投票时间已过  -2 重复投票
+var voteScript = redis.NewScript(`
+local postTime = tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1]) or '0')
+if tonumber(ARGV[4]) - postTime > tonumber(ARGV[5]) or redis.call('ZSCORE', KEYS[4], ARGV[1]) then
+	return -1
+end
+local ov = tonumber(redis.call('ZSCORE', KEYS[3], ARGV[2]) or '0')
+local value = tonumber(ARGV[3])
+if value == ov then
+	return -2
+end
+redis.call('ZINCRBY', KEYS[2], (value - ov) * tonumber(ARGV[6]), ARGV[1])
+if value == 0 then
+	redis.call('ZREM', KEYS[3], ARGV[2])
+else
+	redis.call('ZADD', KEYS[3], value, ARGV[2])
+end
+return 0
+`)
 
-	// 更新：如果这一次投票的值和之前保存的值一致，就提示不允许重复投票
-	if value == ov {
-		return ErrVoteRepeated
+func VoteForPost(userID, postID string, value float64) error {
+	// 1. 判断投票限制
+	// 2. 更新贴子的分数
+	// 3. 记录用户为该贴子投票的数据
+	// 三步在voteScript中原子地执行
+	keys := []string{
+		getRedisKey(KeyPostTimeZSet),
+		getRedisKey(KeyPostScoreZSet),
+		getRedisKey(KeyPostVotedZSetPF + postID),
+		getRedisKey(KeyPostSettledZSet),
 	}
-	var op float64
-	if value > ov {
-		op = 1
-	} else {
-		op = -1
+	ret, err := voteScript.Run(client, keys,
+		postID, userID, value, time.Now().Unix(), oneWeekInSeconds, scorePerVote).Int()
+	if err != nil {
+		return err
 	}
-	diff := math.Abs(ov - value) // 计算两次投票的差值
-	pipeline := client.TxPipeline()
-	pipeline.ZIncrBy(getRedisKey(KeyPostScoreZSet), op*diff*scorePerVote, postID)
-
-	// 3. 记录用户为该贴子投票的数据
-	if value == 0 {
-		pipeline.ZRem(getRedisKey(KeyPostVotedZSetPF+postID), userID)
-	} else {
-		pipeline.ZAdd(getRedisKey(KeyPostVotedZSetPF+postID), redis.Z{
-			Score:  value, // 赞成票还是反对票
-			Member: userID,
-		})
+	switch ret {
+	case -1:
+		return ErrVoteTimeExpire
+	case -2:
+		return ErrVoteRepeated
 	}
-	_, err := pipeline.Exec()
-	return err
+	return nil
 }
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupMiniRedis 使用miniredis代替真实的redis
+func setupMiniRedis(t *testing.T) *miniredis.Miniredis {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run() failed, err:%v\n", err)
+	}
+	client = redis.NewClient(&redis.Options{Addr: s.Addr(), PoolSize: 50})
+	t.Cleanup(func() {
+		_ = client.Close()
+		s.Close()
+	})
+	return s
+}
+
+func TestVoteForPost(t *testing.T) {
+	setupMiniRedis(t)
+	if err := CreatePost(1, 1); err != nil {
+		t.Fatalf("CreatePost failed, err:%v\n", err)
+	}
+	initial := client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val()
+
+	tests := []struct {
+		value float64
+		err   error
+		delta float64 // 相对初始分数的变化
+	}{
+		{1, nil, scorePerVote},
+		{1, ErrVoteRepeated, scorePerVote},
+		{-1, nil, -scorePerVote},
+		{0, nil, 0},
+		{0, ErrVoteRepeated, 0},
+		{-1, nil, -scorePerVote},
+	}
+	for i, tt := range tests {
+		err := VoteForPost("100", "1", tt.value)
+		assert.Equal(t, tt.err, err, "vote %d", i)
+		score := client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val()
+		assert.Equal(t, initial+tt.delta, score, "vote %d", i)
+	}
+	assert.Equal(t, float64(-1), client.ZScore(getRedisKey(KeyPostVotedZSetPF+"1"), "100").Val())
+}
+
+func TestVoteForPostExpired(t *testing.T) {
+	setupMiniRedis(t)
+	client.ZAdd(getRedisKey(KeyPostTimeZSet), redis.Z{
+		Score:  float64(time.Now().Unix() - oneWeekInSeconds - 1),
+		Member: "1",
+	})
+	assert.Equal(t, ErrVoteTimeExpire, VoteForPost("100", "1", 1))
+	// 不存在的帖子也不允许投票
+	assert.Equal(t, ErrVoteTimeExpire, VoteForPost("100", "2", 1))
+	assert.Equal(t, int64(0), client.Exists(getRedisKey(KeyPostVotedZSetPF+"1")).Val())
+}
+
+func TestVoteForPostConcurrent(t *testing.T) {
+	setupMiniRedis(t)
+	if err := CreatePost(1, 1); err != nil {
+		t.Fatalf("CreatePost failed, err:%v\n", err)
+	}
+	initial := client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val()
+
+	// 同一个用户并发投同样的票，只能成功一次
+	const n = 50
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		success  int
+		repeated int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := VoteForPost("100", "1", 1)
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				success++
+			case ErrVoteRepeated:
+				repeated++
+			default:
+				t.Errorf("VoteForPost failed, err:%v\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, success)
+	assert.Equal(t, n-1, repeated)
+	assert.Equal(t, initial+scorePerVote, client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val())
+
+	// 多个用户并发改票，最终分数要和投票记录一致
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := strconv.Itoa(i % 10)
+			_ = VoteForPost(user, "1", float64(i%3-1))
+		}(i)
+	}
+	wg.Wait()
+	var sum float64
+	for _, z := range client.ZRangeWithScores(getRedisKey(KeyPostVotedZSetPF+"1"), 0, -1).Val() {
+		sum += z.Score
+	}
+	assert.Equal(t, initial+sum*scorePerVote, client.ZScore(getRedisKey(KeyPostScoreZSet), "1").Val())
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/models"
+	"bluebell/pkg/snowflake"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// CreateComment 发表评论或回复
+func CreateComment(userID, pid int64, p *models.ParamCreateComment) (comment *models.Comment, err error) {
+	// 1. 帖子必须存在且未被删除
+	if _, err = mysql.GetPostById(pid); err != nil {
+		return nil, err
+	}
+	// 2. 回复的评论必须属于同一个帖子
+	if p.ParentID != 0 {
+		parent, err := mysql.GetCommentById(p.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != pid {
+			return nil, mysql.ErrorCommentNotExist
+		}
+	}
+	// 3. 保存到数据库
+	comment = &models.Comment{
+		ID:       snowflake.GenID(),
+		PostID:   pid,
+		ParentID: p.ParentID,
+		AuthorID: userID,
+		Content:  p.Content,
+	}
+	err = mysql.CreateComment(comment)
+	return
+}
+
+// GetCommentList 分页获取帖子的评论或某条评论的回复
+func GetCommentList(p *models.ParamCommentList) (data []*models.ApiCommentDetail, err error) {
+	// 帖子必须存在且未被删除
+	if _, err = mysql.GetPostById(p.PostID); err != nil {
+		return nil, err
+	}
+	comments, err := mysql.GetCommentList(p)
+	if err != nil {
+		return nil, err
+	}
+	// 根据作者id批量查询作者信息
+	authorIDs := make([]int64, 0, len(comments))
+	seen := make(map[int64]bool, len(comments))
+	for _, comment := range comments {
+		if !seen[comment.AuthorID] {
+			seen[comment.AuthorID] = true
+			authorIDs = append(authorIDs, comment.AuthorID)
+		}
+	}
+	users, err := mysql.GetUsersByIDs(authorIDs)
+	if err != nil {
+		zap.L().Error("mysql.GetUsersByIDs(authorIDs) failed", zap.Error(err))
+		return nil, err
+	}
+	authors := make(map[int64]*models.User, len(users))
+	for _, user := range users {
+		authors[user.UserID] = user
+	}
+
+	data = make([]*models.ApiCommentDetail, 0, len(comments))
+	for _, comment := range comments {
+		user, ok := authors[comment.AuthorID]
+		if !ok {
+			zap.L().Error("author of comment not found",
+				zap.Int64("comment_id", comment.ID),
+				zap.Int64("author_id", comment.AuthorID))
+			continue
+		}
+		data = append(data, &models.ApiCommentDetail{
+			AuthorName: user.Username,
+			Comment:    comment,
+		})
+	}
+	return
+}
+
+// VoteForComment 为评论投票，评论按票数排序时使用净票数
+func VoteForComment(userID int64, p *models.ParamCommentVoteData) error {
+	cid, err := strconv.ParseInt(p.CommentID, 10, 64)
+	if err != nil {
+		return mysql.ErrorInvalidID
+	}
+	return mysql.VoteForComment(cid, userID, p.Direction)
+}
@@ -3,13 +3,74 @@ package logic
 import (
 	"bluebell/dao/mysql"
 	"bluebell/models"
+	"sync"
+	"time"
 )
 
+// 社区信息很少变化，在进程内缓存一段时间，减少组装帖子列表时查询MySQL的次数
+const communityCacheTTL = 10 * time.Minute
+
+var communityCache = struct {
+	sync.RWMutex
+	data   map[int64]*models.CommunityDetail
+	expire time.Time // 到期后整体失效
+}{}
+
 func GetCommunityList() ([]*models.Community, error) {
 	// 查数据库 查找到所有的community 并返回
 	return mysql.GetCommunityList()
 }
 
 func GetCommunityDetail(id int64) (*models.CommunityDetail, error) {
-	return mysql.GetCommunityDetailByID(id)
+	communities, err := getCommunities([]int64{id})
+	if err != nil {
+		return nil, err
+	}
+	community, ok := communities[id]
+	if !ok {
+		return nil, mysql.ErrorInvalidID
+	}
+	return community, nil
+}
+
+// getCommunities 批量获取社区详情，优先使用缓存，不存在的社区不在返回的map中
+func getCommunities(ids []int64) (map[int64]*models.CommunityDetail, error) {
+	communities := make(map[int64]*models.CommunityDetail, len(ids))
+	var missing []int64
+	seen := make(map[int64]bool, len(ids))
+
+	communityCache.RLock()
+	valid := time.Now().Before(communityCache.expire)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if c, ok := communityCache.data[id]; ok && valid {
+			communities[id] = c
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	communityCache.RUnlock()
+	if len(missing) == 0 {
+		return communities, nil
+	}
+
+	// 缓存中没有的去MySQL批量查询
+	list, err := mysql.GetCommunitiesByIDs(missing)
+	if err != nil {
+		return nil, err
+	}
+	communityCache.Lock()
+	if !time.Now().Before(communityCache.expire) {
+		communityCache.data = make(map[int64]*models.CommunityDetail)
+		communityCache.expire = time.Now().Add(communityCacheTTL)
+	}
+	for _, c := range list {
+		communityCache.data[c.ID] = c
+		communities[c.ID] = c
+	}
+	communityCache.Unlock()
+	return communities, nil
 }
@@ -5,10 +5,14 @@ import (
 	"bluebell/dao/redis"
 	"bluebell/models"
 	"bluebell/pkg/snowflake"
+	"errors"
+	"strconv"
 
 	"go.uber.org/zap"
 )
 
+var ErrorNoPermission = errors.New("只有作者才能操作该帖子")
+
 func CreatePost(p *models.Post) (err error) {
 	// 1. 生成post id
 	p.ID = snowflake.GenID()
@@ -18,10 +22,58 @@ func CreatePost(p *models.Post) (err error) {
 		return err
 	}
 	err = redis.CreatePost(p.ID, p.CommunityID)
+	if err != nil {
+		return err
+	}
+	// 计算新帖子在各个排名算法中的分数，失败时等待定时任务重新计算
+	if err := RankPosts([]string{strconv.FormatInt(p.ID, 10)}); err != nil {
+		zap.L().Error("RankPosts failed", zap.Int64("post_id", p.ID), zap.Error(err))
+	}
 	return
 	// 3. 返回
 }
 
+// UpdatePost 编辑帖子，只有作者本人可以编辑
+func UpdatePost(userID, pid int64, p *models.ParamUpdatePost) (err error) {
+	post, err := mysql.GetPostById(pid)
+	if err != nil {
+		return err
+	}
+	if post.AuthorID != userID {
+		return ErrorNoPermission
+	}
+	return mysql.UpdatePost(post, userID, p)
+}
+
+// DeletePost 删除帖子，只有作者本人可以删除
+func DeletePost(userID, pid int64) (err error) {
+	post, err := mysql.GetPostById(pid)
+	if err != nil {
+		return err
+	}
+	if post.AuthorID != userID {
+		return ErrorNoPermission
+	}
+	// 1. MySQL中软删除并保存历史记录
+	if err = mysql.DeletePost(post, userID); err != nil {
+		return err
+	}
+	// 2. 从redis的各个帖子列表中移除
+	return redis.DeletePost(pid, post.CommunityID, rankOrders()...)
+}
+
+// GetPostHistory 查询帖子的编辑历史，只有作者本人可以查看
+func GetPostHistory(userID, pid int64) (history []*models.PostHistory, err error) {
+	post, err := mysql.GetPostById(pid)
+	if err != nil {
+		return nil, err
+	}
+	if post.AuthorID != userID {
+		return nil, ErrorNoPermission
+	}
+	return mysql.GetPostHistory(pid)
+}
+
 // GetPostById 根据帖子id查询帖子详情数据
 func GetPostById(pid int64) (data *models.ApiPostDetail, err error) {
 	// 查询并组合我们接口想用的数据
@@ -40,17 +92,26 @@ func GetPostById(pid int64) (data *models.ApiPostDetail, err error) {
 			zap.Error(err))
 		return
 	}
-	// 根据社区id查询社区详细信息
-	community, err := mysql.GetCommunityDetailByID(post.CommunityID)
+	// 根据社区id查询社区详细信息，优先使用缓存
+	community, err := GetCommunityDetail(post.CommunityID)
 	if err != nil {
-		zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
+		zap.L().Error("GetCommunityDetail(post.CommunityID) failed",
 			zap.Int64("community_id", post.CommunityID),
 			zap.Error(err))
 		return
 	}
+	// 查询评论数
+	commentNum, err := mysql.GetPostCommentNum(pid)
+	if err != nil {
+		zap.L().Error("mysql.GetPostCommentNum(pid) failed",
+			zap.Int64("pid", pid),
+			zap.Error(err))
+		return
+	}
 	// 接口数据拼接
 	data = &models.ApiPostDetail{
 		AuthorName:      user.Username,
+		CommentNum:      commentNum,
 		Post:            post,
 		CommunityDetail: community,
 	}
@@ -63,36 +124,10 @@ func GetPostList(page, size int64) (data []*models.ApiPostDetail, err error) {
 	if err != nil {
 		return nil, err
 	}
-	data = make([]*models.ApiPostDetail, 0, len(posts))
-
-	for _, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
-			continue
-		}
-		// 根据社区id查询社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
-			continue
-		}
-		postDetail := &models.ApiPostDetail{
-			AuthorName:      user.Username,
-			Post:            post,
-			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
-	}
-	return
+	return buildPostDetails(posts)
 }
 
-func GetPostList2(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
+func GetPostList2(userID int64, p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
 	// 2. 去redis查询id列表，此时参数里有按照时间还是分数的获取帖子的值
 	ids, err := redis.GetPostIDsInOrder(p)
 	if err != nil {
@@ -103,50 +138,10 @@ func GetPostList2(p *models.ParamPostList) (data []*models.ApiPostDetail, err er
 		return
 	}
 	zap.L().Debug("GetPostList2", zap.Any("ids", ids))
-	// 3. 根据id去MySQL数据库查询帖子详细信息
-	// 返回的数据还要按照我给定的id的顺序返回
-	posts, err := mysql.GetPostListByIDs(ids)
-	if err != nil {
-		return
-	}
-	zap.L().Debug("GetPostList2", zap.Any("posts", posts))
-	// 提前查询好每篇帖子的投票数
-	voteData, err := redis.GetPostVoteData(ids)
-	if err != nil {
-		return
-	}
-
-	// 将帖子的作者及分区信息查询出来填充到帖子中
-	for idx, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
-			continue
-		}
-		// 根据社区id查询社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
-			continue
-		}
-		postDetail := &models.ApiPostDetail{
-			AuthorName:      user.Username,
-			VoteNum:         voteData[idx],
-			Post:            post,
-			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
-	}
-	return
-
+	return getPostListByIDs(userID, ids)
 }
 
-func GetCommunityPostList(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
+func GetCommunityPostList(userID int64, p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
 	// 2. 去redis查询id列表
 	ids, err := redis.GetCommunityPostIDsInOrder(p)
 	if err != nil {
@@ -157,57 +152,112 @@ func GetCommunityPostList(p *models.ParamPostList) (data []*models.ApiPostDetail
 		return
 	}
 	zap.L().Debug("GetCommunityPostIDsInOrder", zap.Any("ids", ids))
+	return getPostListByIDs(userID, ids)
+}
+
+// getPostListByIDs 根据redis中查到的有序id列表组装帖子列表
+func getPostListByIDs(userID int64, ids []string) (data []*models.ApiPostDetail, err error) {
 	// 3. 根据id去MySQL数据库查询帖子详细信息
 	// 返回的数据还要按照我给定的id的顺序返回
 	posts, err := mysql.GetPostListByIDs(ids)
 	if err != nil {
 		return
 	}
-	zap.L().Debug("GetPostList2", zap.Any("posts", posts))
+	zap.L().Debug("getPostListByIDs", zap.Any("posts", posts))
 	// 提前查询好每篇帖子的投票数
-	voteData, err := redis.GetPostVoteData(ids)
+	voteData, err := GetPostVoteData(ids, userID)
+	if err != nil {
+		return
+	}
+	// 批量查询每篇帖子的评论数
+	commentNums, err := mysql.GetPostCommentNums(ids)
+	if err != nil {
+		return
+	}
+
+	data, err = buildPostDetails(posts)
 	if err != nil {
 		return
 	}
+	// 已删除的帖子查不到，不能按下标对应，按id填充投票数据
+	votes := make(map[string]*models.PostVoteData, len(ids))
+	for idx, id := range ids {
+		votes[id] = voteData[idx]
+	}
+	for _, detail := range data {
+		detail.PostVoteData = votes[strconv.FormatInt(detail.Post.ID, 10)]
+		detail.CommentNum = commentNums[detail.Post.ID]
+	}
+	return
+}
+
+// buildPostDetails 批量查询帖子的作者及分区信息填充到帖子中
+// 作者或分区不存在的帖子会被跳过
+func buildPostDetails(posts []*models.Post) (data []*models.ApiPostDetail, err error) {
+	authorIDs := make([]int64, 0, len(posts))
+	communityIDs := make([]int64, 0, len(posts))
+	seen := make(map[int64]bool, len(posts))
+	for _, post := range posts {
+		if !seen[post.AuthorID] {
+			seen[post.AuthorID] = true
+			authorIDs = append(authorIDs, post.AuthorID)
+		}
+		communityIDs = append(communityIDs, post.CommunityID) // getCommunities会去重
+	}
+	// 根据作者id批量查询作者信息
+	users, err := mysql.GetUsersByIDs(authorIDs)
+	if err != nil {
+		zap.L().Error("mysql.GetUsersByIDs(authorIDs) failed", zap.Error(err))
+		return nil, err
+	}
+	authors := make(map[int64]*models.User, len(users))
+	for _, user := range users {
+		authors[user.UserID] = user
+	}
+	// 根据社区id批量查询社区详细信息，优先使用缓存
+	communities, err := getCommunities(communityIDs)
+	if err != nil {
+		zap.L().Error("getCommunities(communityIDs) failed", zap.Error(err))
+		return nil, err
+	}
 
-	// 将帖子的作者及分区信息查询出来填充到帖子中
-	for idx, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
+	data = make([]*models.ApiPostDetail, 0, len(posts))
+	for _, post := range posts {
+		user, ok := authors[post.AuthorID]
+		if !ok {
+			zap.L().Error("author of post not found",
+				zap.Int64("post_id", post.ID),
+				zap.Int64("author_id", post.AuthorID))
 			continue
 		}
-		// 根据社区id查询社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
+		community, ok := communities[post.CommunityID]
+		if !ok {
+			zap.L().Error("community of post not found",
+				zap.Int64("post_id", post.ID),
+				zap.Int64("community_id", post.CommunityID))
 			continue
 		}
-		postDetail := &models.ApiPostDetail{
+		data = append(data, &models.ApiPostDetail{
 			AuthorName:      user.Username,
-			VoteNum:         voteData[idx],
 			Post:            post,
 			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
+		})
 	}
 	return
 }
 
 // GetPostListNew  将两个查询帖子列表逻辑合二为一的函数
-func GetPostListNew(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
+// userID为当前登录用户的id，未登录时为0
+func GetPostListNew(userID int64, p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
+	// order=hot时使用社区配置的排名算法
+	p.Order = resolveOrder(p)
 	// 根据请求参数的不同，执行不同的逻辑。
 	if p.CommunityID == 0 {
 		// 查所有
-		data, err = GetPostList2(p)
+		data, err = GetPostList2(userID, p)
 	} else {
 		// 根据社区id查询
-		data, err = GetCommunityPostList(p)
+		data, err = GetCommunityPostList(userID, p)
 	}
 	if err != nil {
 		zap.L().Error("GetPostListNew failed", zap.Error(err))
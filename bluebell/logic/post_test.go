@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/models"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver 记录查询次数的假数据库驱动，只支持组装帖子列表用到的用户、社区及已结算投票数据的查询
+// 每个查询条件中的id都返回一条数据
+type fakeDriver struct {
+	queries int64
+}
+
+var testDriver = &fakeDriver{}
+
+func init() {
+	sql.Register("bluebell-fake", testDriver)
+	db, _ := sql.Open("bluebell-fake", "")
+	mysql.InitWithDB(db, "mysql")
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+// count 返回当前的查询次数
+func (d *fakeDriver) count() int64 { return atomic.LoadInt64(&d.queries) }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	atomic.AddInt64(&s.d.queries, 1)
+	rows := &fakeRows{}
+	switch {
+	case strings.Contains(s.query, "from user"):
+		rows.columns = []string{"user_id", "username"}
+		for _, id := range args {
+			rows.values = append(rows.values, []driver.Value{id, fmt.Sprintf("user%d", id)})
+		}
+	case strings.Contains(s.query, "from post where post_id in"):
+		// 已结算帖子的投票数据，每篇帖子50张赞成票
+		rows.columns = []string{"post_id", "up_votes", "down_votes"}
+		for _, id := range args {
+			rows.values = append(rows.values, []driver.Value{id, int64(50), int64(0)})
+		}
+	case strings.Contains(s.query, "from community"):
+		rows.columns = []string{"community_id", "community_name", "introduction", "create_time"}
+		for _, id := range args {
+			rows.values = append(rows.values, []driver.Value{id, fmt.Sprintf("community%d", id), "", time.Unix(0, 0)})
+		}
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakePosts 生成n篇帖子，作者和社区有重复
+func fakePosts(n int) []*models.Post {
+	posts := make([]*models.Post, 0, n)
+	for i := 0; i < n; i++ {
+		posts = append(posts, &models.Post{
+			ID:          int64(i + 1),
+			AuthorID:    int64(i%10 + 1),
+			CommunityID: int64(i%4 + 1),
+		})
+	}
+	return posts
+}
+
+// buildPostDetailsPerPost 逐篇查询作者和社区的写法，用来对比查询次数
+func buildPostDetailsPerPost(posts []*models.Post) (data []*models.ApiPostDetail) {
+	for _, post := range posts {
+		user, err := mysql.GetUserById(post.AuthorID)
+		if err != nil {
+			continue
+		}
+		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
+		if err != nil {
+			continue
+		}
+		data = append(data, &models.ApiPostDetail{
+			AuthorName:      user.Username,
+			Post:            post,
+			CommunityDetail: community,
+		})
+	}
+	return
+}
+
+func resetCommunityCache() {
+	communityCache.Lock()
+	communityCache.data = nil
+	communityCache.expire = time.Time{}
+	communityCache.Unlock()
+}
+
+func TestBuildPostDetails(t *testing.T) {
+	resetCommunityCache()
+	posts := fakePosts(20)
+
+	before := testDriver.count()
+	data, err := buildPostDetails(posts)
+	assert.Nil(t, err)
+	// 作者和社区各查询一次
+	assert.Equal(t, int64(2), testDriver.count()-before)
+	if assert.Len(t, data, len(posts)) {
+		for i, detail := range data {
+			assert.Equal(t, posts[i], detail.Post)
+			assert.Equal(t, fmt.Sprintf("user%d", posts[i].AuthorID), detail.AuthorName)
+			assert.Equal(t, posts[i].CommunityID, detail.CommunityDetail.ID)
+		}
+	}
+
+	// 社区已经缓存，只需要查询作者
+	before = testDriver.count()
+	_, err = buildPostDetails(posts)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), testDriver.count()-before)
+
+	// 逐篇查询时每篇帖子需要两次查询
+	before = testDriver.count()
+	assert.Len(t, buildPostDetailsPerPost(posts), len(posts))
+	assert.Equal(t, int64(2*len(posts)), testDriver.count()-before)
+}
+
+func TestGetCommunityDetailCached(t *testing.T) {
+	resetCommunityCache()
+
+	before := testDriver.count()
+	for i := 0; i < 3; i++ {
+		community, err := GetCommunityDetail(2)
+		assert.Nil(t, err)
+		assert.Equal(t, "community2", community.Name)
+	}
+	assert.Equal(t, int64(1), testDriver.count()-before)
+
+	// 缓存到期后重新查询
+	communityCache.Lock()
+	communityCache.expire = time.Now().Add(-time.Second)
+	communityCache.Unlock()
+	_, err := GetCommunityDetail(2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), testDriver.count()-before)
+}
+
+func BenchmarkBuildPostDetails(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		posts := fakePosts(n)
+		benchmarks := []struct {
+			name string
+			fn   func()
+		}{
+			{"PerPost", func() { buildPostDetailsPerPost(posts) }},
+			{"Batch", func() {
+				resetCommunityCache()
+				_, _ = buildPostDetails(posts)
+			}},
+			{"BatchCached", func() { _, _ = buildPostDetails(posts) }},
+		}
+		for _, bm := range benchmarks {
+			b.Run(fmt.Sprintf("%s/%d", bm.name, n), func(b *testing.B) {
+				bm.fn() // 预热缓存
+				before := testDriver.count()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					bm.fn()
+				}
+				b.ReportMetric(float64(testDriver.count()-before)/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
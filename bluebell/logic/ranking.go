@@ -0,0 +1,237 @@
+package logic
+
+import (
+	"bluebell/dao/redis"
+	"bluebell/models"
+	"bluebell/setting"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 帖子排名算法
+// 推荐阅读：http://www.ruanyifeng.com/blog/algorithm/
+// 每种算法的分数保存在各自的zset中，由定时任务重新计算，发帖和投票时也会更新对应帖子的分数
+// 投票结算后票数不再变化，定时任务只为结算后的帖子重新计算随时间衰减的分数(如HN算法)
+// 线性分数保存在 KeyPostScoreZSet 中，由投票脚本原子地更新，不需要重新计算
+
+const rankBatchSize = 500 // 定时任务每次计算的帖子数量
+
+// timeNow 计算分数时使用的当前时间，测试时可以替换
+var timeNow = time.Now
+
+// PostRankData 计算排名分数需要的帖子数据
+type PostRankData struct {
+	CreateTime int64 // 发帖时间(Unix秒)
+	UpVotes    int64 // 赞成票数
+	DownVotes  int64 // 反对票数
+}
+
+// Ranker 帖子排名算法
+type Ranker interface {
+	// Name 算法名称，即ParamPostList中的order值
+	Name() string
+	// Score 计算帖子在now时刻的分数，分数越大越靠前
+	Score(p *PostRankData, now time.Time) float64
+}
+
+// rankers 所有可用的排名算法
+var rankers = []Ranker{
+	linearRanker{},
+	redditRanker{},
+	hnRanker{},
+}
+
+// GetRanker 根据名称获取排名算法
+func GetRanker(name string) (Ranker, bool) {
+	for _, r := range rankers {
+		if r.Name() == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// rankOrders 返回需要重新计算分数的排名算法的名称
+func rankOrders() []string {
+	orders := make([]string, 0, len(rankers))
+	for _, r := range rankers {
+		if r.Name() != models.OrderScore {
+			orders = append(orders, r.Name())
+		}
+	}
+	return orders
+}
+
+// decayOrders 返回分数随时间衰减的排名算法的名称，投票结算后的帖子也需要定时重新计算
+func decayOrders() []string {
+	var orders []string
+	for _, r := range rankers {
+		if _, ok := r.(decayer); ok {
+			orders = append(orders, r.Name())
+		}
+	}
+	return orders
+}
+
+// decayer 由分数依赖计算时间的排名算法实现
+type decayer interface {
+	decays()
+}
+
+// linearRanker 项目原来的分数：发帖时间 + 432 * 净票数
+type linearRanker struct{}
+
+func (linearRanker) Name() string { return models.OrderScore }
+
+func (linearRanker) Score(p *PostRankData, _ time.Time) float64 {
+	return float64(p.CreateTime + 432*(p.UpVotes-p.DownVotes))
+}
+
+// redditRanker Reddit热度算法：票数取对数，每45000秒(12.5小时)相当于10倍的票数
+type redditRanker struct{}
+
+// redditEpoch Reddit算法使用的起始时间 2005-12-08 07:46:43 UTC
+const redditEpoch = 1134028003
+
+func (redditRanker) Name() string { return models.OrderReddit }
+
+func (redditRanker) Score(p *PostRankData, _ time.Time) float64 {
+	s := float64(p.UpVotes - p.DownVotes)
+	order := math.Log10(math.Max(math.Abs(s), 1))
+	var sign float64
+	switch {
+	case s > 0:
+		sign = 1
+	case s < 0:
+		sign = -1
+	}
+	seconds := float64(p.CreateTime - redditEpoch)
+	return sign*order + seconds/45000
+}
+
+// hnRanker Hacker News重力衰减算法：净票数 / (发帖小时数+2)^1.8
+type hnRanker struct{}
+
+const hnGravity = 1.8
+
+func (hnRanker) Name() string { return models.OrderHN }
+
+func (hnRanker) decays() {}
+
+func (hnRanker) Score(p *PostRankData, now time.Time) float64 {
+	hours := math.Max(float64(now.Unix()-p.CreateTime)/3600, 0)
+	return float64(p.UpVotes-p.DownVotes) / math.Pow(hours+2, hnGravity)
+}
+
+// resolveOrder 把order=hot转换成社区配置的排名算法
+func resolveOrder(p *models.ParamPostList) string {
+	if p.Order != models.OrderHot {
+		return p.Order
+	}
+	name := models.OrderScore
+	if cfg := setting.Conf.RankingConfig; cfg != nil {
+		if n, ok := cfg.Communities[strconv.FormatInt(p.CommunityID, 10)]; ok {
+			name = n
+		} else if cfg.Default != "" {
+			name = cfg.Default
+		}
+	}
+	if _, ok := GetRanker(name); !ok {
+		zap.L().Warn("unknown ranking algorithm", zap.String("name", name))
+		return models.OrderScore
+	}
+	return name
+}
+
+// RankPosts 使用所有排名算法重新计算指定帖子的分数
+func RankPosts(ids []string) error {
+	return rankPosts(ids, rankOrders())
+}
+
+// rankPosts 使用orders中的排名算法重新计算指定帖子的分数
+func rankPosts(ids, orders []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	times, err := redis.GetPostCreateTimes(ids)
+	if err != nil {
+		return err
+	}
+	votes, err := GetPostVoteData(ids, 0)
+	if err != nil {
+		return err
+	}
+	// 跳过已经被删除的帖子
+	rankIDs := make([]string, 0, len(ids))
+	data := make([]*PostRankData, 0, len(ids))
+	for i, id := range ids {
+		if times[i] == 0 {
+			continue
+		}
+		rankIDs = append(rankIDs, id)
+		data = append(data, &PostRankData{
+			CreateTime: times[i],
+			UpVotes:    votes[i].VoteNum,
+			DownVotes:  votes[i].DownVoteNum,
+		})
+	}
+
+	now := timeNow()
+	scores := make([]float64, len(data))
+	for _, name := range orders {
+		r, _ := GetRanker(name)
+		for i, d := range data {
+			scores[i] = r.Score(d, now)
+		}
+		if err := redis.SetPostRankScores(name, rankIDs, scores); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RankAllPosts 重新计算所有帖子的分数，返回计算的帖子数量
+// 投票期限内的帖子使用所有排名算法，投票已到期的帖子只重新计算随时间衰减的分数
+func RankAllPosts() (n int, err error) {
+	n, err = rankPostsBy(redis.GetUnsettledPostIDs, rankOrders())
+	if err != nil {
+		return n, err
+	}
+	m, err := rankPostsBy(redis.GetExpiredPostIDs, decayOrders())
+	return n + m, err
+}
+
+// rankPostsBy 分批查询帖子id并使用orders中的排名算法重新计算分数，返回计算的帖子数量
+func rankPostsBy(list func(offset, count int64) ([]string, error), orders []string) (n int, err error) {
+	if len(orders) == 0 {
+		return 0, nil
+	}
+	for offset := int64(0); ; offset += rankBatchSize {
+		ids, err := list(offset, rankBatchSize)
+		if err != nil {
+			return n, err
+		}
+		if err := rankPosts(ids, orders); err != nil {
+			return n, err
+		}
+		n += len(ids)
+		if len(ids) < rankBatchSize {
+			return n, nil
+		}
+	}
+}
+
+// RunRanking 每隔interval重新计算一次帖子的分数，需要在单独的goroutine中运行
+func RunRanking(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := RankAllPosts(); err != nil {
+			zap.L().Error("RankAllPosts failed", zap.Int("ranked", n), zap.Error(err))
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,111 @@
+package logic
+
+import (
+	"bluebell/dao/redis"
+	"bluebell/models"
+	"bluebell/setting"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankers(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	older := &PostRankData{CreateTime: now.Unix() - 24*3600, UpVotes: 10}
+	newer := &PostRankData{CreateTime: now.Unix() - 3600, UpVotes: 10}
+	popular := &PostRankData{CreateTime: now.Unix() - 3600, UpVotes: 100, DownVotes: 5}
+	disliked := &PostRankData{CreateTime: now.Unix() - 3600, DownVotes: 10}
+
+	for _, name := range []string{models.OrderScore, models.OrderReddit, models.OrderHN} {
+		r, ok := GetRanker(name)
+		if !assert.True(t, ok, name) {
+			continue
+		}
+		assert.Equal(t, name, r.Name())
+		// 票数相同时新帖子靠前，时间相同时票数多的靠前
+		assert.Greater(t, r.Score(newer, now), r.Score(older, now), name)
+		assert.Greater(t, r.Score(popular, now), r.Score(newer, now), name)
+		assert.Less(t, r.Score(disliked, now), r.Score(newer, now), name)
+	}
+
+	_, ok := GetRanker("unknown")
+	assert.False(t, ok)
+}
+
+func TestLinearRanker(t *testing.T) {
+	p := &PostRankData{CreateTime: 1600000000, UpVotes: 3, DownVotes: 1}
+	// 与投票时在redis中累加的分数一致
+	assert.Equal(t, float64(1600000000+2*432), linearRanker{}.Score(p, time.Now()))
+}
+
+func TestHNRankerDecay(t *testing.T) {
+	p := &PostRankData{CreateTime: 1600000000, UpVotes: 50}
+	created := time.Unix(p.CreateTime, 0)
+	fresh := hnRanker{}.Score(p, created)
+	assert.InDelta(t, 50/3.4822022531844965, fresh, 1e-9) // 2^1.8
+	assert.Less(t, hnRanker{}.Score(p, created.Add(24*time.Hour)), fresh)
+}
+
+func TestRankAllPostsDecaysSettledPosts(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run() failed, err:%v\n", err)
+	}
+	defer s.Close()
+	port, _ := strconv.Atoi(s.Port())
+	if err := redis.Init(&setting.RedisConfig{Host: s.Host(), Port: port, PoolSize: 10}); err != nil {
+		t.Fatalf("redis.Init failed, err:%v\n", err)
+	}
+	defer redis.Close()
+	defer func() { timeNow = time.Now }()
+
+	// 投票已结算的帖子，票数从MySQL读取
+	created := float64(time.Now().Unix() - 8*24*3600)
+	s.ZAdd(redis.Prefix+redis.KeyPostTimeZSet, created, "1")
+	s.ZAdd(redis.Prefix+redis.KeyPostSettledZSet, created, "1")
+
+	score := func(now time.Time) float64 {
+		timeNow = func() time.Time { return now }
+		_, err := RankAllPosts()
+		assert.Nil(t, err)
+		v, err := s.ZScore(redis.Prefix+redis.KeyPostRankZSetPF+models.OrderHN, "1")
+		assert.Nil(t, err)
+		return v
+	}
+	first := score(time.Now())
+	assert.Greater(t, first, float64(0))
+	assert.Less(t, score(time.Now().Add(24*time.Hour)), first)
+	assert.Less(t, score(time.Now().Add(48*time.Hour)), score(time.Now().Add(24*time.Hour)))
+}
+
+func TestResolveOrder(t *testing.T) {
+	old := setting.Conf.RankingConfig
+	defer func() { setting.Conf.RankingConfig = old }()
+
+	setting.Conf.RankingConfig = &setting.RankingConfig{
+		Default:     models.OrderReddit,
+		Communities: map[string]string{"2": models.OrderHN, "3": "unknown"},
+	}
+	tests := []struct {
+		communityID int64
+		order       string
+		want        string
+	}{
+		{0, models.OrderTime, models.OrderTime},
+		{2, models.OrderScore, models.OrderScore},
+		{0, models.OrderHot, models.OrderReddit},
+		{1, models.OrderHot, models.OrderReddit},
+		{2, models.OrderHot, models.OrderHN},
+		{3, models.OrderHot, models.OrderScore},
+	}
+	for _, tt := range tests {
+		p := &models.ParamPostList{CommunityID: tt.communityID, Order: tt.order}
+		assert.Equal(t, tt.want, resolveOrder(p), "%d %s", tt.communityID, tt.order)
+	}
+
+	setting.Conf.RankingConfig = nil
+	assert.Equal(t, models.OrderScore, resolveOrder(&models.ParamPostList{Order: models.OrderHot}))
+}
@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 投票结算
+// 帖子发布一周后不再允许投票，定时把redis中的投票结果保存到MySQL并删除投票记录
+
+const (
+	settleBatchSize = 100         // 每次结算的帖子数量
+	settleLockTTL   = time.Minute // 结算一批帖子最多持有锁的时间
+)
+
+// SettleExpiredVotes 结算所有投票已到期的帖子，返回结算的帖子数量
+// 每批帖子都在结算锁中完成，其他实例正在结算时直接返回
+func SettleExpiredVotes() (n int, err error) {
+	for {
+		settled, done, err := settleBatch()
+		n += settled
+		if err != nil || done {
+			return n, err
+		}
+	}
+}
+
+// settleBatch 持有结算锁结算一批帖子，没有更多帖子或锁被其他实例持有时done为true
+func settleBatch() (n int, done bool, err error) {
+	token, ok, err := redis.AcquireSettleLock(settleLockTTL)
+	if err != nil || !ok {
+		return 0, true, err
+	}
+	defer func() {
+		if err := redis.ReleaseSettleLock(token); err != nil {
+			zap.L().Error("redis.ReleaseSettleLock failed", zap.Error(err))
+		}
+	}()
+
+	// 先重试上次中途失败的帖子
+	ids, err := redis.GetSettlingPostIDs()
+	if err != nil {
+		return 0, true, err
+	}
+	if len(ids) == 0 {
+		ids, err = redis.GetUnsettledExpiredPostIDs(settleBatchSize)
+		if err != nil {
+			return 0, true, err
+		}
+	}
+	if len(ids) == 0 {
+		// 全部结算完成后清理已结算的帖子
+		return 0, true, redis.TrimSettledPosts()
+	}
+	for _, id := range ids {
+		if err := settlePost(id); err != nil {
+			// 失败的帖子留在正在结算的set中，下次重试
+			return n, true, err
+		}
+		n++
+	}
+	return n, false, nil
+}
+
+// settlePost 结算一篇帖子：先停止投票并读取结果，写MySQL后再清理redis，中途失败可以安全重试
+func settlePost(id string) error {
+	pid, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return err
+	}
+	up, down, score, err := redis.CloseVote(id)
+	if err == redis.Nil {
+		// 帖子已被删除
+		return redis.FinishSettlement(id)
+	}
+	if err != nil {
+		return err
+	}
+	// 保存每个用户的投票，结算后仍然能返回用户自己的投票
+	userVotes, err := redis.GetPostVotes(id)
+	if err != nil {
+		return err
+	}
+	votes := make(map[int64]int8, len(userVotes))
+	for uid, direction := range userVotes {
+		userID, err := strconv.ParseInt(uid, 10, 64)
+		if err != nil {
+			return err
+		}
+		votes[userID] = direction
+	}
+	// 只更新还没有结算过的帖子，重试时不会覆盖已保存的结果
+	if err := mysql.SaveVoteResult(pid, up, down, score, votes); err != nil {
+		return err
+	}
+	// 定时任务不再计算已结算的帖子，用最终的票数计算最后一次排名分数
+	if err := RankPosts([]string{id}); err != nil {
+		return err
+	}
+	return redis.FinishSettlement(id)
+}
+
+// RunVoteSettlement 每隔interval结算一次到期的投票，需要在单独的goroutine中运行
+func RunVoteSettlement(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := SettleExpiredVotes()
+		if err != nil {
+			zap.L().Error("SettleExpiredVotes failed", zap.Int("settled", n), zap.Error(err))
+			continue
+		}
+		if n > 0 {
+			zap.L().Info("settle expired votes", zap.Int("settled", n))
+		}
+	}
+}
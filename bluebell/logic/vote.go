@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"bluebell/dao/mysql"
 	"bluebell/dao/redis"
 	"bluebell/models"
 	"strconv"
@@ -37,5 +38,44 @@ func VoteForPost(userID int64, p *models.ParamVoteData) error {
 		zap.Int64("userID", userID),
 		zap.String("postID", p.PostID),
 		zap.Int8("direction", p.Direction))
-	return redis.VoteForPost(strconv.Itoa(int(userID)), p.PostID, float64(p.Direction))
+	if err := redis.VoteForPost(strconv.Itoa(int(userID)), p.PostID, float64(p.Direction)); err != nil {
+		return err
+	}
+	// 票数变化后更新帖子在各个排名算法中的分数，失败时等待定时任务重新计算
+	if err := RankPosts([]string{p.PostID}); err != nil {
+		zap.L().Error("RankPosts failed", zap.String("post_id", p.PostID), zap.Error(err))
+	}
+	return nil
+}
+
+// GetPostVoteData 根据ids查询每篇帖子的投票数据，userID不为0时包括该用户的投票
+// 投票已结算的帖子从MySQL查询，用户的投票在结算时保存到了post_vote表
+func GetPostVoteData(ids []string, userID int64) (data []*models.PostVoteData, err error) {
+	var uid string
+	if userID != 0 {
+		uid = strconv.FormatInt(userID, 10)
+	}
+	data, settled, err := redis.GetPostVoteData(ids, uid)
+	if err != nil {
+		return nil, err
+	}
+	var settledIDs []string
+	for i, id := range ids {
+		if settled[i] {
+			settledIDs = append(settledIDs, id)
+		}
+	}
+	if len(settledIDs) == 0 {
+		return data, nil
+	}
+	votes, err := mysql.GetSettledVoteData(settledIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if v, ok := votes[id]; ok && settled[i] {
+			data[i] = v
+		}
+	}
+	return data, nil
 }
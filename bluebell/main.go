@@ -5,11 +5,13 @@ import (
 	"bluebell/dao/mysql"
 	"bluebell/dao/redis"
 	"bluebell/logger"
+	"bluebell/logic"
 	"bluebell/pkg/snowflake"
 	"bluebell/router"
 	"bluebell/setting"
 	"fmt"
 	"os"
+	"time"
 )
 
 // @title bluebell项目接口文档
@@ -61,6 +63,14 @@ func main() {
 		fmt.Printf("init validator trans failed, err:%v\n", err)
 		return
 	}
+	// 启动后台定时任务
+	if cfg := setting.Conf.JobConfig; cfg != nil && cfg.VoteSettleInterval > 0 {
+		go logic.RunVoteSettlement(time.Duration(cfg.VoteSettleInterval) * time.Second)
+	}
+	if cfg := setting.Conf.JobConfig; cfg != nil && cfg.RankInterval > 0 {
+		go logic.RunRanking(time.Duration(cfg.RankInterval) * time.Second)
+	}
+
 	// 注册路由
 	r := router.SetupRouter(setting.Conf.Mode)
 
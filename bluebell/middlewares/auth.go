@@ -41,3 +41,17 @@ func JWTAuthMiddleware() func(c *gin.Context) {
 		c.Next() // 后续的处理请求的函数中 可以用过c.Get(CtxUserIDKey) 来获取当前请求的用户信息
 	}
 }
+
+// JWTOptionalAuthMiddleware 可选的JWT认证中间件
+// 携带有效的Token时和JWTAuthMiddleware一样保存userID，否则按未登录用户继续处理请求
+func JWTOptionalAuthMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if mc, err := jwt.ParseToken(parts[1]); err == nil {
+				c.Set(controller.CtxUserIDKey, mc.UserID)
+			}
+		}
+		c.Next()
+	}
+}
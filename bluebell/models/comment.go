@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// 评论状态
+const (
+	CommentStatusDeleted int32 = 0 // 已删除
+	CommentStatusNormal  int32 = 1 // 正常
+)
+
+// Comment 帖子的评论，ParentID为0表示直接评论帖子，否则是对某条评论的回复
+type Comment struct {
+	ID         int64     `json:"id,string" db:"comment_id"`               // 评论id
+	PostID     int64     `json:"post_id,string" db:"post_id"`             // 所属帖子id
+	ParentID   int64     `json:"parent_id,string" db:"parent_id"`         // 父评论id
+	AuthorID   int64     `json:"author_id,string" db:"author_id"`         // 作者id
+	Votes      int64     `json:"votes" db:"votes"`                        // 净票数(赞成票-反对票)
+	ReplyNum   int64     `json:"reply_num" db:"reply_num"`                // 回复数
+	Status     int32     `json:"status" db:"status"`                      // 评论状态
+	Content    string    `json:"content" db:"content" binding:"required"` // 评论内容
+	CreateTime time.Time `json:"create_time" db:"create_time"`            // 评论时间
+}
+
+// ApiCommentDetail 评论列表接口的结构体
+type ApiCommentDetail struct {
+	AuthorName string `json:"author_name"` // 作者
+	*Comment          // 嵌入评论结构体
+}
@@ -42,10 +42,73 @@ CREATE TABLE `post` (
     `author_id` bigint(20) NOT NULL COMMENT '作者的用户id',
     `community_id` bigint(20) NOT NULL COMMENT '所属社区',
     `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态',
+    `up_votes` bigint(20) NOT NULL DEFAULT '0' COMMENT '结算后的赞成票数',
+    `down_votes` bigint(20) NOT NULL DEFAULT '0' COMMENT '结算后的反对票数',
+    `score` double NOT NULL DEFAULT '0' COMMENT '结算后的分数',
+    `settled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '投票是否已结算',
     `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     PRIMARY KEY (`id`),
     UNIQUE KEY `idx_post_id` (`post_id`),
     KEY `idx_author_id` (`author_id`),
     KEY `idx_community_id` (`community_id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `post_history`;
+CREATE TABLE `post_history` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+    `editor_id` bigint(20) NOT NULL COMMENT '编辑者的用户id',
+    `action` tinyint(4) NOT NULL DEFAULT '1' COMMENT '操作类型,1编辑 2删除',
+    `title` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '操作前的标题',
+    `content` varchar(8192) COLLATE utf8mb4_general_ci NOT NULL COMMENT '操作前的内容',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_post_id` (`post_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `post_vote`;
+CREATE TABLE `post_vote` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+    `user_id` bigint(20) NOT NULL COMMENT '投票的用户id',
+    `direction` tinyint(4) NOT NULL COMMENT '投票值,1赞成 -1反对',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '结算时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_post_user` (`post_id`, `user_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `comment`;
+CREATE TABLE `comment` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+    `post_id` bigint(20) NOT NULL COMMENT '所属帖子id',
+    `parent_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '父评论id,0表示直接评论帖子',
+    `author_id` bigint(20) NOT NULL COMMENT '评论作者的用户id',
+    `content` varchar(2048) COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论内容',
+    `votes` bigint(20) NOT NULL DEFAULT '0' COMMENT '净票数',
+    `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '评论状态',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_comment_id` (`comment_id`),
+    KEY `idx_post_parent` (`post_id`, `parent_id`),
+    KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `comment_vote`;
+CREATE TABLE `comment_vote` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+    `user_id` bigint(20) NOT NULL COMMENT '投票的用户id',
+    `direction` tinyint(4) NOT NULL COMMENT '投票值,1赞成 -1反对',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_comment_user` (`comment_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -3,8 +3,12 @@ package models
 // 定义请求的参数结构体
 
 const (
-	OrderTime  = "time"
-	OrderScore = "score"
+	OrderTime   = "time"
+	OrderScore  = "score"  // 发帖时间加票数的线性分数
+	OrderReddit = "reddit" // Reddit热度算法
+	OrderHN     = "hn"     // Hacker News重力衰减算法
+	OrderHot    = "hot"    // 使用社区配置的排名算法
+	OrderVotes  = "votes"  // 评论按票数排序
 )
 
 // ParamSignUp 注册请求参数
@@ -20,6 +24,33 @@ type ParamLogin struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ParamUpdatePost 编辑帖子请求参数
+type ParamUpdatePost struct {
+	Title   string `json:"title" binding:"required"`   // 帖子标题
+	Content string `json:"content" binding:"required"` // 帖子内容
+}
+
+// ParamCreateComment 发表评论请求参数
+type ParamCreateComment struct {
+	ParentID int64  `json:"parent_id,string"`           // 回复的评论id，直接评论帖子时为空
+	Content  string `json:"content" binding:"required"` // 评论内容
+}
+
+// ParamCommentList 获取评论列表query string参数
+type ParamCommentList struct {
+	PostID   int64  `json:"-" form:"-"`                                                   // 帖子id，从URL中获取
+	ParentID int64  `json:"parent_id" form:"parent_id"`                                   // 为空时查询直接评论帖子的评论
+	Page     int64  `json:"page" form:"page" binding:"min=1" example:"1"`                 // 页码
+	Size     int64  `json:"size" form:"size" binding:"min=1,max=100" example:"10"`        // 每页数据量
+	Order    string `json:"order" form:"order" binding:"oneof=time votes" example:"time"` // 排序依据
+}
+
+// ParamCommentVoteData 评论投票数据
+type ParamCommentVoteData struct {
+	CommentID string `json:"comment_id" binding:"required"`            // 评论id
+	Direction int8   `json:"direction,string" binding:"oneof=1 0 -1" ` // 赞成票(1)还是反对票(-1)取消投票(0)
+}
+
 // ParamVoteData 投票数据
 type ParamVoteData struct {
 	// UserID 从请求中获取当前的用户
@@ -29,8 +60,8 @@ type ParamVoteData struct {
 
 // ParamPostList 获取帖子列表query string参数
 type ParamPostList struct {
-	CommunityID int64  `json:"community_id" form:"community_id"`   // 可以为空
-	Page        int64  `json:"page" form:"page" example:"1"`       // 页码
-	Size        int64  `json:"size" form:"size" example:"10"`      // 每页数据量
-	Order       string `json:"order" form:"order" example:"score"` // 排序依据
+	CommunityID int64  `json:"community_id" form:"community_id"`                                            // 可以为空
+	Page        int64  `json:"page" form:"page" example:"1"`                                                // 页码
+	Size        int64  `json:"size" form:"size" example:"10"`                                               // 每页数据量
+	Order       string `json:"order" form:"order" binding:"oneof=time score reddit hn hot" example:"score"` // 排序依据
 }
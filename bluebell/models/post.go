@@ -4,6 +4,18 @@ import "time"
 
 // 内存对齐概念
 
+// 帖子状态
+const (
+	PostStatusDeleted int32 = 0 // 已删除(软删除)
+	PostStatusNormal  int32 = 1 // 正常
+)
+
+// 帖子历史记录的操作类型
+const (
+	PostActionEdit   int8 = 1 // 编辑
+	PostActionDelete int8 = 2 // 删除
+)
+
 type Post struct {
 	ID          int64     `json:"id,string" db:"post_id"`                            // 帖子id
 	AuthorID    int64     `json:"author_id" db:"author_id"`                          // 作者id
@@ -14,10 +26,29 @@ type Post struct {
 	CreateTime  time.Time `json:"create_time" db:"create_time"`                      // 帖子创建时间
 }
 
+// PostHistory 帖子的历史版本，每次编辑或删除前保存一份
+type PostHistory struct {
+	PostID     int64     `json:"post_id,string" db:"post_id"`     // 帖子id
+	EditorID   int64     `json:"editor_id,string" db:"editor_id"` // 编辑者id
+	Action     int8      `json:"action" db:"action"`              // 操作类型(1编辑 2删除)
+	Title      string    `json:"title" db:"title"`                // 操作前的标题
+	Content    string    `json:"content" db:"content"`            // 操作前的内容
+	CreateTime time.Time `json:"create_time" db:"create_time"`    // 操作时间
+}
+
+// PostVoteData 帖子的投票数据
+type PostVoteData struct {
+	VoteNum     int64 `json:"vote_num"`          // 赞成票数
+	DownVoteNum int64 `json:"down_vote_num"`     // 反对票数
+	NetVoteNum  int64 `json:"net_vote_num"`      // 净票数(赞成票-反对票)
+	MyVote      *int8 `json:"my_vote,omitempty"` // 当前用户的投票(1/0/-1)，未登录时为空
+}
+
 // ApiPostDetail 帖子详情接口的结构体
 type ApiPostDetail struct {
 	AuthorName       string             `json:"author_name"` // 作者
-	VoteNum          int64              `json:"vote_num"`    // 投票数
+	CommentNum       int64              `json:"comment_num"` // 评论数
+	*PostVoteData                       // 嵌入投票数据
 	*Post                               // 嵌入帖子结构体
 	*CommunityDetail `json:"community"` // 嵌入社区信息
 }
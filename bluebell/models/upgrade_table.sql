@@ -0,0 +1,85 @@
+-- 已有数据库的升级脚本：只新增缺少的列和表，不会删除已有的数据，可以重复执行
+-- 新部署直接执行 create_table.sql
+
+DROP PROCEDURE IF EXISTS `add_column_if_not_exists`;
+DELIMITER $$
+CREATE PROCEDURE `add_column_if_not_exists`(IN tbl varchar(64), IN col varchar(64), IN def varchar(512))
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND COLUMN_NAME = col) THEN
+        SET @ddl = CONCAT('ALTER TABLE `', tbl, '` ADD COLUMN `', col, '` ', def);
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END $$
+DELIMITER ;
+
+
+-- 帖子状态及投票结算结果
+CALL add_column_if_not_exists('post', 'status', "tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态' AFTER `community_id`");
+CALL add_column_if_not_exists('post', 'up_votes', "bigint(20) NOT NULL DEFAULT '0' COMMENT '结算后的赞成票数' AFTER `status`");
+CALL add_column_if_not_exists('post', 'down_votes', "bigint(20) NOT NULL DEFAULT '0' COMMENT '结算后的反对票数' AFTER `up_votes`");
+CALL add_column_if_not_exists('post', 'score', "double NOT NULL DEFAULT '0' COMMENT '结算后的分数' AFTER `down_votes`");
+CALL add_column_if_not_exists('post', 'settled', "tinyint(1) NOT NULL DEFAULT '0' COMMENT '投票是否已结算' AFTER `score`");
+
+
+CREATE TABLE IF NOT EXISTS `post_history` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+    `editor_id` bigint(20) NOT NULL COMMENT '编辑者的用户id',
+    `action` tinyint(4) NOT NULL DEFAULT '1' COMMENT '操作类型,1编辑 2删除',
+    `title` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '操作前的标题',
+    `content` varchar(8192) COLLATE utf8mb4_general_ci NOT NULL COMMENT '操作前的内容',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_post_id` (`post_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- 早期的 post_history 只记录编辑，没有操作类型
+CALL add_column_if_not_exists('post_history', 'action', "tinyint(4) NOT NULL DEFAULT '1' COMMENT '操作类型,1编辑 2删除' AFTER `editor_id`");
+
+
+CREATE TABLE IF NOT EXISTS `post_vote` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+    `user_id` bigint(20) NOT NULL COMMENT '投票的用户id',
+    `direction` tinyint(4) NOT NULL COMMENT '投票值,1赞成 -1反对',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '结算时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_post_user` (`post_id`, `user_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+CREATE TABLE IF NOT EXISTS `comment` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+    `post_id` bigint(20) NOT NULL COMMENT '所属帖子id',
+    `parent_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '父评论id,0表示直接评论帖子',
+    `author_id` bigint(20) NOT NULL COMMENT '评论作者的用户id',
+    `content` varchar(2048) COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论内容',
+    `votes` bigint(20) NOT NULL DEFAULT '0' COMMENT '净票数',
+    `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '评论状态',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_comment_id` (`comment_id`),
+    KEY `idx_post_parent` (`post_id`, `parent_id`),
+    KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+CREATE TABLE IF NOT EXISTS `comment_vote` (
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+    `user_id` bigint(20) NOT NULL COMMENT '投票的用户id',
+    `direction` tinyint(4) NOT NULL COMMENT '投票值,1赞成 -1反对',
+    `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_comment_user` (`comment_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP PROCEDURE IF EXISTS `add_column_if_not_exists`;
@@ -48,19 +48,27 @@ func SetupRouter(mode string) *gin.Engine {
 	v1.POST("/login", controller.LoginHandler)
 
 	// 根据时间或分数获取帖子列表
-	v1.GET("/posts2", controller.GetPostListHandler2)
+	v1.GET("/posts2", middlewares.JWTOptionalAuthMiddleware(), controller.GetPostListHandler2) // 登录时返回当前用户的投票
 	v1.GET("/posts", controller.GetPostListHandler)
 	v1.GET("/community", controller.CommunityHandler)
 	v1.GET("/community/:id", controller.CommunityDetailHandler)
 	v1.GET("/post/:id", controller.GetPostDetailHandler)
+	v1.GET("/post/:id/comments", controller.GetCommentListHandler)
 
 	v1.Use(middlewares.JWTAuthMiddleware()) // 应用JWT认证中间件
 
 	{
 		v1.POST("/post", controller.CreatePostHandler)
+		// 编辑、删除帖子，只有作者本人可以操作
+		v1.PUT("/post/:id", controller.UpdatePostHandler)
+		v1.DELETE("/post/:id", controller.DeletePostHandler)
+		v1.GET("/post/:id/history", controller.GetPostHistoryHandler)
+		// 发表评论或回复
+		v1.POST("/post/:id/comments", controller.CreateCommentHandler)
 
 		// 投票
 		v1.POST("/vote", controller.PostVoteController)
+		v1.POST("/comment/vote", controller.CommentVoteHandler)
 	}
 
 	pprof.Register(r) // 注册pprof相关路由
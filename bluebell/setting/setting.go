@@ -22,6 +22,9 @@ type AppConfig struct {
 	*LogConfig   `mapstructure:"log"`
 	*MySQLConfig `mapstructure:"mysql"`
 	*RedisConfig `mapstructure:"redis"`
+	*JobConfig   `mapstructure:"job"`
+
+	*RankingConfig `mapstructure:"ranking"`
 }
 
 type MySQLConfig struct {
@@ -43,6 +46,19 @@ type RedisConfig struct {
 	MinIdleConns int    `mapstructure:"min_idle_conns"`
 }
 
+// JobConfig 后台定时任务配置，单位：秒
+type JobConfig struct {
+	VoteSettleInterval int `mapstructure:"vote_settle_interval"`
+	RankInterval       int `mapstructure:"rank_interval"`
+}
+
+// RankingConfig 帖子排名算法配置，order=hot时使用
+// Communities 的key是社区id，没有配置的社区使用 Default
+type RankingConfig struct {
+	Default     string            `mapstructure:"default"`
+	Communities map[string]string `mapstructure:"communities"`
+}
+
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
 	Filename   string `mapstructure:"filename"`
@@ -0,0 +1,308 @@
+/*
+响应缓存中间件：
+缓存 GET 请求的 200 响应，key 由路径、query 参数和 Vary 请求头组成，缓存数据保存在可替换的 CacheStore 中。
+响应会带上 ETag，请求的 If-None-Match 命中时返回 304；
+请求头 Cache-Control: no-cache 时跳过缓存重新计算，并用新结果刷新缓存。
+携带 Authorization 或 Cookie 的请求不使用缓存，除非这些请求头在 Vary 中参与了 key 的计算。
+缓存只保存和重放实体相关的响应头，其他中间件设置的响应头保持不变。
+*/
+
+package gee
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse 缓存的响应
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	ETag   string
+}
+
+// CacheStore 响应缓存的存储后端
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+	Delete(key string)
+}
+
+// CacheConfig 缓存中间件的配置
+type CacheConfig struct {
+	Store CacheStore    // 默认 NewLRUCacheStore(1024)
+	TTL   time.Duration // 默认的缓存时间
+	// RouteTTL 按路由模式(如 /hello/:name)单独设置缓存时间，<=0 表示该路由不缓存
+	RouteTTL map[string]time.Duration
+	// Vary 参与缓存 key 计算的请求头，例如 Accept-Language
+	Vary []string
+}
+
+// credentialHeaders 携带用户身份的请求头，响应可能因人而异
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// entityHeaders 缓存中保存并重放的响应头
+var entityHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Type",
+	"ETag",
+	"Expires",
+	"Last-Modified",
+	"Vary",
+}
+
+// Cache 响应缓存中间件
+func Cache(config CacheConfig) HandlerFunc {
+	if config.Store == nil {
+		config.Store = NewLRUCacheStore(1024)
+	}
+	return func(c *Context) {
+		if c.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		ttl := config.TTL
+		if routeTTL, ok := config.RouteTTL[c.FullPath()]; ok {
+			ttl = routeTTL
+		}
+		if ttl <= 0 || c.FullPath() == "" || !config.shareable(c.Req.Header) {
+			c.Next()
+			return
+		}
+
+		key := config.key(c)
+		if !hasCacheDirective(c.Req.Header, "no-cache") {
+			if resp, ok := config.Store.Get(key); ok {
+				c.SetHeader("X-Cache", "HIT")
+				writeCachedResponse(c, resp)
+				c.index = len(c.handlers)
+				return
+			}
+		}
+
+		// 缓冲响应，等 handler 执行完再计算 ETag 并写出
+		w := &cacheWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		// handler panic 时恢复原始 writer，保证 Recovery 能写出错误响应
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		status := w.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := &CachedResponse{Status: status, Header: entityHeader(c.Writer.Header()), Body: w.body.Bytes()}
+		if status == http.StatusOK {
+			resp.ETag = computeETag(resp.Body)
+			resp.Header.Set("ETag", resp.ETag)
+			if cacheable(c.Writer.Header()) {
+				config.Store.Set(key, resp, ttl)
+			}
+		}
+		c.SetHeader("X-Cache", "MISS")
+		writeCachedResponse(c, resp)
+	}
+}
+
+// key 缓存 key 为 路径?排序后的 query|Vary 请求头
+func (config *CacheConfig) key(c *Context) string {
+	var b strings.Builder
+	b.WriteString(c.Path)
+	b.WriteByte('?')
+	b.WriteString(c.Req.URL.Query().Encode())
+	for _, name := range config.Vary {
+		b.WriteByte('|')
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(c.Req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// shareable 携带身份信息的请求头只有参与 key 的计算时，响应才能在请求之间共享
+func (config *CacheConfig) shareable(header http.Header) bool {
+	for _, name := range credentialHeaders {
+		if header.Get(name) == "" {
+			continue
+		}
+		varied := false
+		for _, v := range config.Vary {
+			if strings.EqualFold(v, name) {
+				varied = true
+				break
+			}
+		}
+		if !varied {
+			return false
+		}
+	}
+	return true
+}
+
+// entityHeader 复制 header 中需要缓存的响应头
+func entityHeader(header http.Header) http.Header {
+	h := make(http.Header)
+	for _, name := range entityHeaders {
+		if v := header.Values(name); len(v) > 0 {
+			h[name] = append([]string(nil), v...)
+		}
+	}
+	return h
+}
+
+// writeCachedResponse 写出缓存的响应，只覆盖缓存中保存的响应头
+func writeCachedResponse(c *Context, resp *CachedResponse) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	if resp.ETag != "" && etagMatch(c.Req.Header.Get("If-None-Match"), resp.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(resp.Status, resp.Body)
+}
+
+// cacheable 设置了 cookie 或声明不可缓存的响应不进入缓存
+func cacheable(header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	return !hasCacheDirective(header, "no-store") && !hasCacheDirective(header, "private")
+}
+
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func computeETag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatch If-None-Match 使用弱比较，支持多个值和 *
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheWriter 缓冲状态码和响应体，header 直接写入原始的 ResponseWriter
+type cacheWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// LRUCacheStore 基于内存的 LRU CacheStore，超过容量时淘汰最久未使用的条目
+type LRUCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+// NewLRUCacheStore 是 LRUCacheStore 的构造函数，capacity 为最多缓存的条目数
+func NewLRUCacheStore(capacity int) *LRUCacheStore {
+	return &LRUCacheStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (s *LRUCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ele, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := ele.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		s.removeElement(ele)
+		return nil, false
+	}
+	s.ll.MoveToFront(ele)
+	return entry.resp, true
+}
+
+func (s *LRUCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if ele, ok := s.items[key]; ok {
+		s.ll.MoveToFront(ele)
+		entry := ele.Value.(*lruEntry)
+		entry.resp, entry.expires = resp, expires
+		return
+	}
+	s.items[key] = s.ll.PushFront(&lruEntry{key: key, resp: resp, expires: expires})
+	for s.capacity > 0 && s.ll.Len() > s.capacity {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+func (s *LRUCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ele, ok := s.items[key]; ok {
+		s.removeElement(ele)
+	}
+}
+
+// Len 当前缓存的条目数
+func (s *LRUCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *LRUCacheStore) removeElement(ele *list.Element) {
+	s.ll.Remove(ele)
+	delete(s.items, ele.Value.(*lruEntry).key)
+}
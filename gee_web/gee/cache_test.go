@@ -0,0 +1,178 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getWithHeader 发送带请求头的 GET 请求
+func getWithHeader(r http.Handler, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCache(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{
+		TTL:      time.Minute,
+		RouteTTL: map[string]time.Duration{"/nocache": 0},
+		Vary:     []string{"Accept-Language"},
+	}))
+	r.GET("/hello/:name", func(c *Context) {
+		calls++
+		c.String(http.StatusOK, "hello %s %s", c.Param("name"), c.Req.Header.Get("Accept-Language"))
+	})
+	r.GET("/nocache", func(c *Context) {
+		calls++
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := getWithHeader(r, "/hello/gee?b=2&a=1", nil)
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("X-Cache") != "MISS" || etag == "" || w.Body.String() != "hello gee " {
+		t.Fatalf("first request should miss, got %v %q", w.Header(), w.Body.String())
+	}
+	w = getWithHeader(r, "/hello/gee?a=1&b=2", nil)
+	if w.Header().Get("X-Cache") != "HIT" || w.Body.String() != "hello gee " || calls != 1 {
+		t.Fatalf("query order should not matter, got %v %q calls=%d", w.Header(), w.Body.String(), calls)
+	}
+	if w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatal("cached headers should be replayed")
+	}
+
+	w = getWithHeader(r, "/hello/gee?a=1&b=2", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("matching If-None-Match should return 304, got %d", w.Code)
+	}
+
+	w = getWithHeader(r, "/hello/gee?a=1&b=2", map[string]string{"Accept-Language": "zh"})
+	if w.Header().Get("X-Cache") != "MISS" || calls != 2 {
+		t.Fatalf("vary header should be part of the key, got %v calls=%d", w.Header(), calls)
+	}
+
+	w = getWithHeader(r, "/hello/gee?a=1&b=2", map[string]string{"Cache-Control": "no-cache"})
+	if w.Header().Get("X-Cache") != "MISS" || calls != 3 {
+		t.Fatalf("no-cache should bypass the cache, got %v calls=%d", w.Header(), calls)
+	}
+
+	getWithHeader(r, "/nocache", nil)
+	getWithHeader(r, "/nocache", nil)
+	if calls != 5 {
+		t.Fatalf("route with zero ttl should not be cached, calls=%d", calls)
+	}
+}
+
+func TestCachePrivateRequests(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{TTL: time.Minute}))
+	r.GET("/me", func(c *Context) {
+		calls++
+		c.SetHeader("X-Handler", "1")
+		c.String(http.StatusOK, "user %s", c.Req.Header.Get("Authorization"))
+	})
+
+	for i, header := range []map[string]string{
+		{"Authorization": "alice"},
+		{"Authorization": "bob"},
+		{"Cookie": "session=alice"},
+		{"Cookie": "session=bob"},
+	} {
+		w := getWithHeader(r, "/me", header)
+		if w.Header().Get("X-Cache") != "" || calls != i+1 {
+			t.Fatalf("request with %v must not use the cache, got %v calls=%d", header, w.Header(), calls)
+		}
+	}
+	w := getWithHeader(r, "/me", map[string]string{"Authorization": "alice"})
+	if w.Body.String() != "user alice" {
+		t.Fatalf("got another user's response %q", w.Body.String())
+	}
+
+	getWithHeader(r, "/me", nil)
+	w = getWithHeader(r, "/me", nil)
+	if w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("anonymous request should hit, got %v", w.Header())
+	}
+	if w.Header().Get("X-Handler") != "" {
+		t.Fatal("non-entity headers must not be replayed from the cache")
+	}
+}
+
+func TestCacheReplaysOnlyEntityHeaders(t *testing.T) {
+	remaining := "5"
+	r := New()
+	r.Use(func(c *Context) {
+		// 模拟限流中间件在缓存之前设置的最新响应头
+		c.SetHeader("RateLimit-Remaining", remaining)
+		c.Next()
+	})
+	r.Use(Cache(CacheConfig{TTL: time.Minute}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	remaining = "4"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("second request should hit, got %v", w.Header())
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "4" {
+		t.Fatalf("cache must not overwrite RateLimit-Remaining with a stale value, got %q", got)
+	}
+	if w.Header().Get("Content-Type") != "text/plain" || w.Header().Get("ETag") == "" {
+		t.Fatalf("entity headers should be replayed, got %v", w.Header())
+	}
+}
+
+func TestCacheVaryOnCredentials(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{TTL: time.Minute, Vary: []string{"Authorization"}}))
+	r.GET("/me", func(c *Context) {
+		calls++
+		c.String(http.StatusOK, "user %s", c.Req.Header.Get("Authorization"))
+	})
+	for _, user := range []string{"alice", "bob", "alice"} {
+		w := getWithHeader(r, "/me", map[string]string{"Authorization": user})
+		if w.Body.String() != "user "+user {
+			t.Fatalf("got %q for %s", w.Body.String(), user)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("Authorization in Vary should be cached per user, calls=%d", calls)
+	}
+}
+
+func TestLRUCacheStore(t *testing.T) {
+	s := NewLRUCacheStore(2)
+	s.Set("a", &CachedResponse{}, time.Minute)
+	s.Set("b", &CachedResponse{}, time.Minute)
+	s.Get("a")
+	s.Set("c", &CachedResponse{}, time.Minute)
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("least recently used entry should be evicted")
+	}
+	if _, ok := s.Get("a"); !ok || s.Len() != 2 {
+		t.Fatal("recently used entry should be kept")
+	}
+	s.Set("d", &CachedResponse{}, -time.Second)
+	if _, ok := s.Get("d"); ok {
+		t.Fatal("expired entry should not be returned")
+	}
+}
+
+func TestETagMatch(t *testing.T) {
+	if !etagMatch(`"x", W/"abc"`, `"abc"`) || !etagMatch("*", `"abc"`) || etagMatch(`"x"`, `"abc"`) {
+		t.Fatal("unexpected etag match result")
+	}
+}
@@ -7,7 +7,10 @@ package gee
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type H map[string]interface{}
@@ -21,13 +24,19 @@ type Context struct {
 	Path   string
 	Method string
 	Params map[string]string
+	// fullPath 匹配到的路由模式，例如 /hello/:name
+	fullPath string
 	// response info
 	StatusCode int
+	// Keys 存放本次请求范围内的键值对，供中间件之间传递数据
+	Keys map[string]interface{}
 	// middleware
 	handlers []HandlerFunc
 	index    int
 	// engine pointer
 	engine *Engine
+	// cookie
+	sameSite http.SameSite
 }
 
 // newContext 创建一个新的 context
@@ -55,6 +64,11 @@ func (c *Context) Fail(code int, err string) {
 	c.JSON(code, H{"message": err})
 }
 
+// FullPath 返回匹配到的路由模式，未匹配时为空字符串
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) Param(key string) string {
 	value, _ := c.Params[key]
 	return value
@@ -70,6 +84,104 @@ func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// Set 在 context 中存储键值对
+func (c *Context) Set(key string, value interface{}) {
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 从 context 中取出键值对
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	value, exists = c.Keys[key]
+	return
+}
+
+// Cookie 获得请求中指定名称的 cookie 值，值会被 url 解码
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	val, _ := url.QueryUnescape(cookie.Value)
+	return val, nil
+}
+
+// SetCookie 向响应中写入 cookie，需要在写入状态码之前调用
+func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		SameSite: c.sameSite,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	})
+}
+
+// SetSameSite 设置之后 SetCookie 写入的 cookie 的 SameSite 属性
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
+
+// ClientIP 获得客户端 IP，默认使用 RemoteAddr。
+// 对端是 Engine.TrustedProxies 中的可信代理时，从右向左查找 X-Forwarded-For 中
+// 第一个不可信的地址，没有 X-Forwarded-For 时使用 X-Real-Ip
+func (c *Context) ClientIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		return ""
+	}
+	if !c.isTrustedProxy(ip) {
+		return ip
+	}
+	if xff := c.Req.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break // 无法解析的地址之前的内容都不可信
+			}
+			ip = hop
+			if !c.isTrustedProxy(hop) {
+				break
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(c.Req.Header.Get("X-Real-Ip")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return ip
+}
+
+// isTrustedProxy 判断 ip 是否在 Engine.TrustedProxies 中
+func (c *Context) isTrustedProxy(ip string) bool {
+	if c.engine == nil || len(c.engine.TrustedProxies) == 0 {
+		return false
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, proxy := range c.engine.TrustedProxies {
+		if strings.Contains(proxy, "/") {
+			if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(addr) {
+				return true
+			}
+		} else if p := net.ParseIP(proxy); p != nil && p.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // Status 状态码的设置
 func (c *Context) Status(code int) {
 	c.StatusCode = code
@@ -80,6 +192,12 @@ func (c *Context) SetHeader(key string, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
+// Redirect 重定向到 location
+func (c *Context) Redirect(code int, location string) {
+	c.StatusCode = code
+	http.Redirect(c.Writer, c.Req, location, code)
+}
+
 // 以下是 String/Data/JSON/HTML 的快速构造方法
 
 func (c *Context) String(code int, format string, values ...interface{}) {
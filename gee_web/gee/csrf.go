@@ -0,0 +1,147 @@
+/*
+CSRF 防护：
+每个会话生成一个随机 token 保存在 Session 中，HTML 表单通过隐藏字段提交，
+AJAX 请求通过请求头提交。GET/HEAD/OPTIONS/TRACE 之外的请求都会校验 token。
+依赖 Sessions 中间件，需在其之后注册。
+*/
+
+package gee
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+const (
+	csrfSessionKey = "gee/csrf"
+	csrfContextKey = "gee/csrf"
+)
+
+// CSRFConfig CSRF 中间件的配置
+type CSRFConfig struct {
+	FieldName  string // 表单字段名，默认 _csrf
+	HeaderName string // 请求头名，默认 X-CSRF-Token
+	// ExemptPrefixes 不做校验的路由前缀，例如使用 bearer token 鉴权的 API 分组
+	// 按路径段匹配，/api 匹配 /api 和 /api/users，不匹配 /apiary
+	ExemptPrefixes []string
+	// ExemptBearer 为 true 时，携带 Authorization: Bearer 的请求不做校验
+	ExemptBearer bool
+	// Exempt 自定义的豁免规则
+	Exempt func(c *Context) bool
+	// ErrorHandler 校验失败时调用，默认返回 403
+	ErrorHandler HandlerFunc
+}
+
+// CSRF 使用默认配置的 CSRF 中间件
+func CSRF() HandlerFunc {
+	return CSRFWithConfig(CSRFConfig{})
+}
+
+// CSRFWithConfig 使用自定义配置的 CSRF 中间件
+func CSRFWithConfig(config CSRFConfig) HandlerFunc {
+	if config.FieldName == "" {
+		config.FieldName = "_csrf"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context) {
+			c.Fail(http.StatusForbidden, "invalid csrf token")
+		}
+	}
+	return func(c *Context) {
+		if config.exempt(c) {
+			c.Next()
+			return
+		}
+
+		session := DefaultSession(c)
+		token, _ := session.Get(csrfSessionKey).(string)
+		if token == "" {
+			var err error
+			if token, err = randomToken(); err != nil {
+				c.Fail(http.StatusInternalServerError, err.Error())
+				return
+			}
+			session.Set(csrfSessionKey, token)
+			if err = session.Save(); err != nil {
+				c.Fail(http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		c.Set(csrfContextKey, token)
+
+		if !isSafeMethod(c.Method) {
+			sent := c.Req.Header.Get(config.HeaderName)
+			if sent == "" {
+				sent = c.Req.PostFormValue(config.FieldName)
+			}
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				c.index = len(c.handlers)
+				config.ErrorHandler(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func (config *CSRFConfig) exempt(c *Context) bool {
+	for _, prefix := range config.ExemptPrefixes {
+		if hasPathPrefix(c.Path, prefix) {
+			return true
+		}
+	}
+	if config.ExemptBearer && strings.HasPrefix(c.Req.Header.Get("Authorization"), "Bearer ") {
+		return true
+	}
+	return config.Exempt != nil && config.Exempt(c)
+}
+
+// hasPathPrefix 判断 path 是否以 prefix 开头，且 prefix 在路径段的边界结束
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// CSRFToken 获得当前请求的 CSRF token，用于渲染模板或返回给前端
+func CSRFToken(c *Context) string {
+	token, _ := c.Get(csrfContextKey)
+	s, _ := token.(string)
+	return s
+}
+
+// CSRFField 生成包含 token 的隐藏表单字段（字段名为默认的 _csrf），可通过 SetFuncMap 注册为模板函数
+// 例如：r.SetFuncMap(template.FuncMap{"csrfField": gee.CSRFField})
+// 模板中使用 {{ csrfField .csrfToken }}
+// 自定义了 CSRFConfig.FieldName 时使用 CSRFFieldFor
+func CSRFField(token string) template.HTML {
+	return CSRFFieldFor(CSRFConfig{})(token)
+}
+
+// CSRFFieldFor 返回使用 config 中字段名的 CSRFField，应传入与 CSRFWithConfig 相同的配置
+// 例如：r.SetFuncMap(template.FuncMap{"csrfField": gee.CSRFFieldFor(config)})
+func CSRFFieldFor(config CSRFConfig) func(token string) template.HTML {
+	name := config.FieldName
+	if name == "" {
+		name = "_csrf"
+	}
+	return func(token string) template.HTML {
+		return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+			template.HTMLEscapeString(name), template.HTMLEscapeString(token)))
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
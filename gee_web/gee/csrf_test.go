@@ -0,0 +1,100 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	r := New()
+	r.Use(Sessions("gee_session", NewMemoryStore(), []byte("secret")))
+	r.Use(CSRFWithConfig(CSRFConfig{ExemptPrefixes: []string{"/api"}}))
+	r.GET("/form", func(c *Context) {
+		c.String(http.StatusOK, "%s", CSRFToken(c))
+	})
+	r.POST("/form", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.POST("/api/items", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.POST("/apiary", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := doRequest(r, "/form")
+	token := w.Body.String()
+	cookies := w.Result().Cookies()
+	if token == "" || len(cookies) != 1 {
+		t.Fatalf("GET should issue a token and a session cookie, got %q %v", token, cookies)
+	}
+
+	post := func(target string, header, field string) int {
+		form := url.Values{}
+		if field != "" {
+			form.Set("_csrf", field)
+		}
+		req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("/form", "", ""); code != http.StatusForbidden {
+		t.Fatalf("POST without token should be forbidden, got %d", code)
+	}
+	if code := post("/form", "", "wrong"); code != http.StatusForbidden {
+		t.Fatalf("POST with wrong token should be forbidden, got %d", code)
+	}
+	if code := post("/form", token, ""); code != http.StatusOK {
+		t.Fatalf("POST with header token should pass, got %d", code)
+	}
+	if code := post("/form", "", token); code != http.StatusOK {
+		t.Fatalf("POST with form token should pass, got %d", code)
+	}
+	if code := post("/api/items", "", ""); code != http.StatusOK {
+		t.Fatalf("exempt prefix should skip the check, got %d", code)
+	}
+	if code := post("/apiary", "", ""); code != http.StatusForbidden {
+		t.Fatalf("exempt prefix should match on a segment boundary, got %d", code)
+	}
+}
+
+func TestCSRFExemptBearer(t *testing.T) {
+	r := New()
+	r.Use(Sessions("gee_session", NewMemoryStore(), []byte("secret")))
+	r.Use(CSRFWithConfig(CSRFConfig{ExemptBearer: true}))
+	r.POST("/items", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("POST", "/items", nil)
+	req.Header.Set("Authorization", "Bearer xxx")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bearer request should skip the check, got %d", w.Code)
+	}
+}
+
+func TestCSRFField(t *testing.T) {
+	field := string(CSRFField(`a"b`))
+	if field != `<input type="hidden" name="_csrf" value="a&#34;b">` {
+		t.Fatalf("unexpected field: %s", field)
+	}
+}
+
+func TestCSRFFieldFor(t *testing.T) {
+	field := string(CSRFFieldFor(CSRFConfig{FieldName: "token"})("abc"))
+	if field != `<input type="hidden" name="token" value="abc">` {
+		t.Fatalf("unexpected field: %s", field)
+	}
+}
@@ -2,7 +2,6 @@ package gee
 
 import (
 	"html/template"
-	"log"
 	"net/http"
 	"path"
 	"strings"
@@ -25,8 +24,20 @@ type (
 		*RouterGroup
 		router        *router
 		groups        []*RouterGroup     // store all groups
+		routes        []RouteInfo        // store all routes, for debug route table
 		htmlTemplates *template.Template // for html render
 		funcMap       template.FuncMap   // for html render
+
+		// RedirectTrailingSlash 开启后，路径只有末尾 '/' 与注册的路由不同时，
+		// 重定向到注册的形式，例如注册了 /foo/ 时 /foo 重定向到 /foo/
+		RedirectTrailingSlash bool
+		// RedirectFixedPath 开启后，先用 CleanPath 去掉多余的 '/'、'.'、'..'，
+		// 再忽略大小写查找路由，找到后重定向到修正后的路径，例如 /FOO 和 /..//Foo 重定向到 /foo
+		RedirectFixedPath bool
+		// TrustedProxies 可信反向代理的 IP 或 CIDR，例如 "10.0.0.0/8"。
+		// 只有直接连接的对端在列表中时，ClientIP 才会使用 X-Forwarded-For 和 X-Real-Ip，
+		// 默认为空，即只使用 RemoteAddr
+		TrustedProxies []string
 	}
 )
 
@@ -66,8 +77,15 @@ func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 // addRoute 添加路由
 func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
 	pattern := group.prefix + comp
-	log.Printf("Route %4s - %s", method, pattern)
+	handlerName := nameOfFunction(handler)
+	debugPrint("Route %4s - %-25s --> %s", method, pattern, handlerName)
 	group.engine.router.addRoute(method, pattern, handler)
+	group.engine.routes = append(group.engine.routes, RouteInfo{
+		Method:      method,
+		Path:        pattern,
+		Handler:     handlerName,
+		HandlerFunc: handler,
+	})
 }
 
 // GET defines the method to add GET request
@@ -115,6 +133,8 @@ func (engine *Engine) LoadHTMLGlob(pattern string) {
 
 // Run defines the method to start a http server
 func (engine *Engine) Run(addr string) (err error) {
+	engine.debugPrintRouteTable()
+	debugPrint("Listening and serving HTTP on %s", addr)
 	// 只要 engine 实现了 ServeHTTP 接口，就可以调用 http.ListenAndServe
 	return http.ListenAndServe(addr, engine)
 }
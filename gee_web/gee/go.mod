@@ -1,2 +1,3 @@
-module gee
-
+module gee
+
+go 1.24
@@ -0,0 +1,258 @@
+/*
+请求指标统计，输出 Prometheus 文本格式(text exposition format 0.0.4)，不依赖第三方库。
+路由标签使用匹配到的 trie 路由模式（如 /hello/:name），而不是原始路径，避免标签基数爆炸。
+*/
+
+package gee
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unmatchedRoute 未匹配到任何路由时使用的标签值
+const unmatchedRoute = "<unmatched>"
+
+var (
+	// DefaultLatencyBuckets 请求耗时直方图的默认分桶，单位秒
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets 响应大小直方图的默认分桶，单位字节
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Metrics 保存所有路由的请求指标
+type Metrics struct {
+	mu             sync.Mutex
+	latencyBuckets []float64
+	sizeBuckets    []float64
+	requests       map[requestLabels]uint64
+	inFlight       map[routeLabels]int64
+	latency        map[routeLabels]*histogram
+	sizes          map[routeLabels]*histogram
+}
+
+type routeLabels struct {
+	method string
+	route  string
+}
+
+type requestLabels struct {
+	routeLabels
+	code int
+}
+
+// NewMetrics 是 Metrics 的构造函数，使用默认分桶
+func NewMetrics() *Metrics {
+	return NewMetricsWithBuckets(DefaultLatencyBuckets, DefaultSizeBuckets)
+}
+
+// NewMetricsWithBuckets 使用自定义分桶创建 Metrics，分桶需升序排列
+func NewMetricsWithBuckets(latencyBuckets, sizeBuckets []float64) *Metrics {
+	return &Metrics{
+		latencyBuckets: latencyBuckets,
+		sizeBuckets:    sizeBuckets,
+		requests:       make(map[requestLabels]uint64),
+		inFlight:       make(map[routeLabels]int64),
+		latency:        make(map[routeLabels]*histogram),
+		sizes:          make(map[routeLabels]*histogram),
+	}
+}
+
+// Middleware 统计请求数、耗时、并发数和响应大小的中间件
+func (m *Metrics) Middleware() HandlerFunc {
+	return func(c *Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := routeLabels{method: c.Method, route: route}
+
+		m.mu.Lock()
+		m.inFlight[labels]++
+		m.mu.Unlock()
+
+		w := &metricsWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		start := time.Now()
+
+		defer func() {
+			elapsed := time.Since(start).Seconds()
+			status := w.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.inFlight[labels]--
+			m.requests[requestLabels{routeLabels: labels, code: status}]++
+			m.histogram(m.latency, labels, m.latencyBuckets).observe(elapsed)
+			m.histogram(m.sizes, labels, m.sizeBuckets).observe(float64(w.size))
+		}()
+
+		c.Next()
+	}
+}
+
+func (m *Metrics) histogram(hs map[routeLabels]*histogram, labels routeLabels, buckets []float64) *histogram {
+	h, ok := hs[labels]
+	if !ok {
+		h = &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		hs[labels] = h
+	}
+	return h
+}
+
+// Handler 输出指标的 handler，例如 r.GET("/metrics", m.Handler())
+func (m *Metrics) Handler() HandlerFunc {
+	return func(c *Context) {
+		m.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// ServeHTTP 实现 http.Handler，便于挂载到其他 mux 上
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	bw := bufio.NewWriter(w)
+	m.WriteText(bw)
+	bw.Flush()
+}
+
+// WriteText 以 Prometheus 文本格式写出所有指标
+func (m *Metrics) WriteText(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP gee_http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE gee_http_requests_total counter")
+	requestKeys := make([]requestLabels, 0, len(m.requests))
+	for k := range m.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		if requestKeys[i].routeLabels != requestKeys[j].routeLabels {
+			return requestKeys[i].routeLabels.less(requestKeys[j].routeLabels)
+		}
+		return requestKeys[i].code < requestKeys[j].code
+	})
+	for _, k := range requestKeys {
+		fmt.Fprintf(w, "gee_http_requests_total{%s,code=\"%d\"} %d\n", k.routeLabels, k.code, m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP gee_http_requests_in_flight Number of HTTP requests currently being served.")
+	fmt.Fprintln(w, "# TYPE gee_http_requests_in_flight gauge")
+	inFlightKeys := make([]routeLabels, 0, len(m.inFlight))
+	for k := range m.inFlight {
+		inFlightKeys = append(inFlightKeys, k)
+	}
+	for _, k := range sortRouteLabels(inFlightKeys) {
+		fmt.Fprintf(w, "gee_http_requests_in_flight{%s} %d\n", k, m.inFlight[k])
+	}
+
+	writeHistograms(w, "gee_http_request_duration_seconds", "HTTP request latency in seconds.", m.latency)
+	writeHistograms(w, "gee_http_response_size_bytes", "HTTP response size in bytes.", m.sizes)
+}
+
+func writeHistograms(w io.Writer, name, help string, hs map[routeLabels]*histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	keys := make([]routeLabels, 0, len(hs))
+	for k := range hs {
+		keys = append(keys, k)
+	}
+	for _, k := range sortRouteLabels(keys) {
+		h := hs[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, k, formatFloat(upper), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, k, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, k, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, k, h.count)
+	}
+}
+
+func sortRouteLabels(keys []routeLabels) []routeLabels {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+func (l routeLabels) less(o routeLabels) bool {
+	if l.route != o.route {
+		return l.route < o.route
+	}
+	return l.method < o.method
+}
+
+// String 输出标签，例如 method="GET",route="/hello/:name"
+func (l routeLabels) String() string {
+	return fmt.Sprintf(`method="%s",route="%s"`, escapeLabel(l.method), escapeLabel(l.route))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// histogram 非累积的分桶计数，输出时再累加
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(v float64) {
+	h.count++
+	h.sum += v
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// metricsWriter 记录响应状态码和写出的字节数
+type metricsWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *metricsWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *metricsWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush 支持流式响应
+func (w *metricsWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,53 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetricsWithBuckets([]float64{1, 10}, []float64{5, 100})
+	r := New()
+	r.Use(m.Middleware())
+	r.GET("/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+	r.GET("/metrics", m.Handler())
+
+	doRequest(r, "/hello/geektutu")
+	doRequest(r, "/hello/gee")
+	doRequest(r, "/missing")
+
+	w := doRequest(r, "/metrics")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE gee_http_requests_total counter",
+		`gee_http_requests_total{method="GET",route="/hello/:name",code="200"} 2`,
+		`gee_http_requests_total{method="GET",route="<unmatched>",code="404"} 1`,
+		`gee_http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		`gee_http_request_duration_seconds_bucket{method="GET",route="/hello/:name",le="+Inf"} 2`,
+		`gee_http_request_duration_seconds_count{method="GET",route="/hello/:name"} 2`,
+		// "hello geektutu" 14 字节，"hello gee" 9 字节
+		`gee_http_response_size_bytes_bucket{method="GET",route="/hello/:name",le="5"} 0`,
+		`gee_http_response_size_bytes_bucket{method="GET",route="/hello/:name",le="100"} 2`,
+		`gee_http_response_size_bytes_sum{method="GET",route="/hello/:name"} 23`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics output should contain %q\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/hello/geektutu") || strings.Contains(body, "/missing") {
+		t.Fatal("raw paths should not be used as route labels")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("unexpected escaped label %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+运行模式：
+debug   打印路由注册信息、路由表和可疑配置的警告（默认）
+release 不打印调试信息
+test    用于单元测试，不打印调试信息
+可以通过环境变量 GEE_MODE 或 SetMode 设置。
+*/
+
+package gee
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	DebugMode   = "debug"
+	ReleaseMode = "release"
+	TestMode    = "test"
+)
+
+// EnvGeeMode 设置运行模式的环境变量
+const EnvGeeMode = "GEE_MODE"
+
+var geeMode = DebugMode
+
+func init() {
+	if mode := os.Getenv(EnvGeeMode); mode != "" {
+		SetMode(mode)
+	}
+}
+
+// SetMode 设置运行模式，取值为 DebugMode、ReleaseMode 或 TestMode
+func SetMode(value string) {
+	switch value {
+	case DebugMode, ReleaseMode, TestMode:
+		geeMode = value
+	default:
+		panic("gee: unknown mode " + value)
+	}
+}
+
+// Mode 返回当前的运行模式
+func Mode() string {
+	return geeMode
+}
+
+// IsDebugging 是否处于 debug 模式
+func IsDebugging() bool {
+	return geeMode == DebugMode
+}
+
+func debugPrint(format string, values ...interface{}) {
+	if IsDebugging() {
+		log.Printf("[GEE-debug] "+format, values...)
+	}
+}
+
+func debugPrintWarning(format string, values ...interface{}) {
+	debugPrint("[WARNING] "+format, values...)
+}
+
+// RouteInfo 已注册路由的信息
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Handler     string // handler 的函数名
+	HandlerFunc HandlerFunc
+	Middlewares int // 请求该路由时会执行的中间件数量
+}
+
+// Routes 返回所有已注册的路由，按注册顺序排列
+func (engine *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(engine.routes))
+	for i, route := range engine.routes {
+		route.Middlewares = engine.middlewareCount(route.Path)
+		routes[i] = route
+	}
+	return routes
+}
+
+// middlewareCount 与 ServeHTTP 中选择中间件的规则一致
+func (engine *Engine) middlewareCount(pattern string) int {
+	count := 0
+	for _, group := range engine.groups {
+		if strings.HasPrefix(pattern, group.prefix) {
+			count += len(group.middlewares)
+		}
+	}
+	return count
+}
+
+// debugPrintRouteTable 启动时打印路由表
+func (engine *Engine) debugPrintRouteTable() {
+	if !IsDebugging() {
+		return
+	}
+	debugPrintWarning("Running in %q mode. Switch to %q mode in production: gee.SetMode(gee.ReleaseMode)", DebugMode, ReleaseMode)
+	if len(engine.routes) == 0 {
+		debugPrintWarning("No routes registered")
+		return
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARES")
+	for _, route := range engine.Routes() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", route.Method, route.Path, route.Handler, route.Middlewares)
+	}
+	w.Flush()
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		debugPrint("%s", line)
+	}
+}
+
+func nameOfFunction(f interface{}) string {
+	if f == nil || reflect.ValueOf(f).IsNil() {
+		return "<nil>"
+	}
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
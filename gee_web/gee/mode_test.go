@@ -0,0 +1,83 @@
+package gee
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func captureLog(f func()) string {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+	f()
+	return buf.String()
+}
+
+func TestSetMode(t *testing.T) {
+	defer SetMode(Mode())
+
+	SetMode(ReleaseMode)
+	if IsDebugging() {
+		t.Fatal("release mode should not be debugging")
+	}
+	out := captureLog(func() {
+		New().GET("/", func(c *Context) {})
+	})
+	if out != "" {
+		t.Fatalf("release mode should not print routes, got %q", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unknown mode should panic")
+		}
+	}()
+	SetMode("unknown")
+}
+
+func indexHandler(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.Use(Logger())
+	v1 := r.Group("/v1")
+	v1.Use(Recovery(), Logger())
+	r.GET("/", indexHandler)
+	v1.POST("/hello/:name", indexHandler)
+
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("should have 2 routes, got %d", len(routes))
+	}
+	if routes[0].Method != "GET" || routes[0].Path != "/" || routes[0].Handler != "gee.indexHandler" || routes[0].Middlewares != 1 {
+		t.Fatalf("unexpected route %+v", routes[0])
+	}
+	if routes[1].Path != "/v1/hello/:name" || routes[1].Middlewares != 3 {
+		t.Fatalf("unexpected route %+v", routes[1])
+	}
+}
+
+func TestDebugRouteTableAndWarnings(t *testing.T) {
+	defer SetMode(Mode())
+	SetMode(DebugMode)
+
+	out := captureLog(func() {
+		r := New()
+		r.GET("/hello/:name", indexHandler)
+		r.GET("/hello/:id", indexHandler)
+		r.GET("/hello/:name", indexHandler)
+		r.debugPrintRouteTable()
+	})
+	for _, expected := range []string{
+		"[WARNING] Route GET /hello/:id shares the wildcard segment of /hello/:name",
+		"[WARNING] Route GET /hello/:name is registered more than once",
+		"METHOD  PATH",
+		"gee.indexHandler",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("debug output should contain %q\n%s", expected, out)
+		}
+	}
+}
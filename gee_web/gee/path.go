@@ -0,0 +1,21 @@
+package gee
+
+import "path"
+
+// CleanPath 返回规范化的 URL 路径，规则与 httprouter.CleanPath 相同：
+// 合并多个 '/'，去掉 '.' 和 '..'，保留末尾的 '/'，空路径返回 "/"
+// 例如 /p//go/./doc/ -> /p/go/doc/，/../p -> /p
+func CleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	// path.Clean 会去掉末尾的 '/'，这里加回来
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
@@ -0,0 +1,233 @@
+/*
+限流中间件：
+按客户端 IP、Context 中的用户 ID 或自定义 key 限流，支持令牌桶和滑动窗口两种算法。
+限流状态保存在可替换的 RateLimitStore 中，默认使用进程内存。
+响应中携带 RateLimit-Limit/RateLimit-Remaining/RateLimit-Reset 头，超限时返回 429 和 Retry-After。
+*/
+
+package gee
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitState 一个 key 的限流状态，不同算法使用不同的字段
+type RateLimitState struct {
+	// 令牌桶
+	Tokens float64
+	Last   time.Time
+	// 滑动窗口
+	WindowStart time.Time
+	PrevCount   int
+	CurrCount   int
+	// Expires 之后状态等同于初始状态，存储后端可以将其删除
+	Expires time.Time
+}
+
+// RateLimitResult 一次限流判断的结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // 配额完全恢复所需的时间
+	RetryAfter time.Duration // 被拒绝时，距离下一次可以请求的时间
+}
+
+// RateLimitAlgorithm 限流算法，根据状态判断本次请求是否放行并更新状态
+type RateLimitAlgorithm interface {
+	Take(state *RateLimitState, now time.Time) RateLimitResult
+}
+
+// RateLimitStore 限流状态的存储后端，Update 需要保证对同一个 key 的读取和更新是原子的
+type RateLimitStore interface {
+	Update(key string, fn func(state *RateLimitState)) error
+}
+
+// TokenBucket 令牌桶算法，每秒补充 Rate 个令牌，最多存放 Burst 个
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+func (tb TokenBucket) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	burst := float64(tb.Burst)
+	if state.Last.IsZero() {
+		state.Tokens = burst
+	} else {
+		state.Tokens = math.Min(burst, state.Tokens+now.Sub(state.Last).Seconds()*tb.Rate)
+	}
+	state.Last = now
+
+	result := RateLimitResult{Limit: tb.Burst}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = tb.duration(1 - state.Tokens)
+	}
+	result.Remaining = int(state.Tokens)
+	result.Reset = tb.duration(burst - state.Tokens)
+	state.Expires = now.Add(result.Reset)
+	return result
+}
+
+func (tb TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / tb.Rate * float64(time.Second))
+}
+
+// SlidingWindow 滑动窗口算法，任意 Window 时间内最多 Limit 次请求
+// 使用上一个窗口计数按时间加权估算，只需保存两个计数
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (sw SlidingWindow) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	start := now.Truncate(sw.Window)
+	if !state.WindowStart.Equal(start) {
+		if state.WindowStart.Equal(start.Add(-sw.Window)) {
+			state.PrevCount = state.CurrCount
+		} else {
+			state.PrevCount = 0
+		}
+		state.CurrCount = 0
+		state.WindowStart = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(sw.Window)
+	estimate := float64(state.PrevCount)*weight + float64(state.CurrCount)
+
+	result := RateLimitResult{Limit: sw.Limit, Reset: start.Add(sw.Window).Sub(now)}
+	if estimate+1 <= float64(sw.Limit) {
+		state.CurrCount++
+		estimate++
+		result.Allowed = true
+	} else if state.CurrCount+1 > sw.Limit || state.PrevCount == 0 {
+		// 当前窗口已满，等到下一个窗口
+		result.RetryAfter = result.Reset
+	} else {
+		// 等待上一个窗口的权重下降到足够放行一次请求
+		need := 1 - float64(sw.Limit-state.CurrCount-1)/float64(state.PrevCount)
+		result.RetryAfter = time.Duration(need*float64(sw.Window)) - elapsed
+	}
+	result.Remaining = int(math.Max(0, float64(sw.Limit)-math.Ceil(estimate)))
+	state.Expires = start.Add(2 * sw.Window)
+	return result
+}
+
+// MemoryRateLimitStore 基于内存的 RateLimitStore，过期的状态会被定期清理
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	states  map[string]*RateLimitState
+	updates int
+}
+
+// NewMemoryRateLimitStore 是 MemoryRateLimitStore 的构造函数
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{states: make(map[string]*RateLimitState)}
+}
+
+func (m *MemoryRateLimitStore) Update(key string, fn func(state *RateLimitState)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	state, ok := m.states[key]
+	if !ok || (!state.Expires.IsZero() && now.After(state.Expires)) {
+		state = &RateLimitState{}
+		m.states[key] = state
+	}
+	fn(state)
+
+	// 每 1024 次更新清理一次过期的状态
+	m.updates++
+	if m.updates%1024 == 0 {
+		for k, s := range m.states {
+			if !s.Expires.IsZero() && now.After(s.Expires) {
+				delete(m.states, k)
+			}
+		}
+	}
+	return nil
+}
+
+// RateLimitConfig 限流中间件的配置
+type RateLimitConfig struct {
+	Algorithm RateLimitAlgorithm      // 必填，TokenBucket 或 SlidingWindow
+	Store     RateLimitStore          // 默认 NewMemoryRateLimitStore()
+	KeyFunc   func(c *Context) string // 默认 KeyByClientIP
+}
+
+// RateLimit 限流中间件
+func RateLimit(config RateLimitConfig) HandlerFunc {
+	switch algorithm := config.Algorithm.(type) {
+	case nil:
+		panic("gee: RateLimit requires an algorithm")
+	case TokenBucket:
+		if algorithm.Rate <= 0 || algorithm.Burst < 1 {
+			panic("gee: TokenBucket requires Rate > 0 and Burst >= 1")
+		}
+	case SlidingWindow:
+		if algorithm.Limit < 1 || algorithm.Window <= 0 {
+			panic("gee: SlidingWindow requires Limit >= 1 and Window > 0")
+		}
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByClientIP
+	}
+	return func(c *Context) {
+		var result RateLimitResult
+		now := time.Now()
+		err := config.Store.Update(config.KeyFunc(c), func(state *RateLimitState) {
+			result = config.Algorithm.Take(state, now)
+		})
+		if err != nil {
+			// 存储后端故障时放行，避免限流组件拖垮整个服务
+			log.Printf("gee: rate limit store failed: %v", err)
+			c.Next()
+			return
+		}
+
+		c.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.Fail(http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+// KeyByClientIP 按客户端 IP 限流
+func KeyByClientIP(c *Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByContext 按 Context 中保存的值（例如鉴权中间件写入的用户 ID）限流，取不到时退回按 IP 限流
+func KeyByContext(key string) func(c *Context) string {
+	return func(c *Context) string {
+		if v, ok := c.Get(key); ok && v != nil {
+			return fmt.Sprintf("%s:%v", key, v)
+		}
+		return KeyByClientIP(c)
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
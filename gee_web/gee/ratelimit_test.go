@@ -0,0 +1,173 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := TokenBucket{Rate: 1, Burst: 2}
+	state := &RateLimitState{}
+	now := time.Unix(1000, 0)
+
+	if r := tb.Take(state, now); !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("first request should be allowed, got %+v", r)
+	}
+	if r := tb.Take(state, now); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("second request should be allowed, got %+v", r)
+	}
+	r := tb.Take(state, now)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("third request should be limited for 1s, got %+v", r)
+	}
+	if r := tb.Take(state, now.Add(time.Second)); !r.Allowed {
+		t.Fatalf("token should be refilled after 1s, got %+v", r)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sw := SlidingWindow{Limit: 2, Window: time.Minute}
+	state := &RateLimitState{}
+	start := time.Unix(6000, 0) // 恰好是窗口起点
+
+	for i := 0; i < 2; i++ {
+		if r := sw.Take(state, start); !r.Allowed {
+			t.Fatalf("request %d should be allowed, got %+v", i, r)
+		}
+	}
+	if r := sw.Take(state, start.Add(30*time.Second)); r.Allowed || r.RetryAfter != 30*time.Second {
+		t.Fatalf("window is full, should retry in 30s, got %+v", r)
+	}
+	// 下一个窗口过去一半时，上一个窗口的 2 次请求按权重计为 1 次
+	if r := sw.Take(state, start.Add(90*time.Second)); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("weighted previous window should leave room for one request, got %+v", r)
+	}
+	if r := sw.Take(state, start.Add(90*time.Second)); r.Allowed {
+		t.Fatalf("request should be limited, got %+v", r)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		if user := c.Query("user"); user != "" {
+			c.Set("userID", user)
+		}
+		c.Next()
+	})
+	r.Use(RateLimit(RateLimitConfig{
+		Algorithm: TokenBucket{Rate: 0.5, Burst: 1},
+		KeyFunc:   KeyByContext("userID"),
+	}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	get := func(target, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/", "10.0.0.1")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first request should pass with headers, got %d %v", w.Code, w.Header())
+	}
+	w = get("/", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("second request should be limited, got %d %v", w.Code, w.Header())
+	}
+	if w = get("/", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("another ip should have its own bucket, got %d", w.Code)
+	}
+	if w = get("/?user=1", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("user key should have its own bucket, got %d", w.Code)
+	}
+	if w = get("/?user=1", "10.0.0.3"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("same user from another ip should share the bucket, got %d", w.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := New()
+	tests := []struct {
+		trusted    []string
+		remoteAddr string
+		xff        string
+		realIP     string
+		want       string
+	}{
+		// 默认不信任任何代理，忽略转发头
+		{nil, "1.2.3.4:80", "9.9.9.9", "8.8.8.8", "1.2.3.4"},
+		// 对端不是可信代理
+		{[]string{"10.0.0.0/8"}, "1.2.3.4:80", "9.9.9.9", "", "1.2.3.4"},
+		// 从右向左跳过可信代理
+		{[]string{"10.0.0.0/8"}, "10.0.0.1:80", "7.7.7.7, 9.9.9.9, 10.0.0.2", "", "9.9.9.9"},
+		{[]string{"10.0.0.1"}, "10.0.0.1:80", "9.9.9.9", "", "9.9.9.9"},
+		// 全部是可信代理时使用最左边的地址
+		{[]string{"10.0.0.0/8"}, "10.0.0.1:80", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		// 无法解析的地址
+		{[]string{"10.0.0.0/8"}, "10.0.0.1:80", "garbage, 10.0.0.2", "", "10.0.0.2"},
+		{[]string{"10.0.0.0/8"}, "10.0.0.1:80", "", "8.8.8.8", "8.8.8.8"},
+		{[]string{"10.0.0.0/8"}, "10.0.0.1:80", "", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		r.TrustedProxies = tt.trusted
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-Ip", tt.realIP)
+		}
+		c := newContext(httptest.NewRecorder(), req)
+		c.engine = r
+		if ip := c.ClientIP(); ip != tt.want {
+			t.Errorf("ClientIP() with trusted %v, remote %s, xff %q = %s, want %s",
+				tt.trusted, tt.remoteAddr, tt.xff, ip, tt.want)
+		}
+	}
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	r := New()
+	r.Use(RateLimit(RateLimitConfig{Algorithm: TokenBucket{Rate: 0.5, Burst: 1}}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	for i, xff := range []string{"1.1.1.1", "2.2.2.2"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", xff)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if i == 1 && w.Code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed X-Forwarded-For must not get a new bucket, got %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitInvalidAlgorithm(t *testing.T) {
+	for _, algorithm := range []RateLimitAlgorithm{
+		nil,
+		TokenBucket{Rate: 0, Burst: 1},
+		TokenBucket{Rate: -1, Burst: 1},
+		TokenBucket{Rate: 1, Burst: 0},
+		SlidingWindow{Limit: 1, Window: 0},
+		SlidingWindow{Limit: 0, Window: time.Second},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RateLimit(%#v) should panic", algorithm)
+				}
+			}()
+			RateLimit(RateLimitConfig{Algorithm: algorithm})
+		}()
+	}
+}
@@ -55,6 +55,13 @@ func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
 	if !ok {
 		r.roots[method] = &node{}
 	}
+	// 可疑配置的警告
+	if _, ok := r.handlers[key]; ok {
+		debugPrintWarning("Route %s %s is registered more than once, the last handler wins", method, pattern)
+	}
+	if wild := r.roots[method].wildConflict(parts); wild != "" {
+		debugPrintWarning("Route %s %s shares the wildcard segment of %s, they will match the same requests", method, pattern, wild)
+	}
 	// 从对应的树中插入路由
 	r.roots[method].insert(pattern, parts, 0)
 	r.handlers[key] = handler
@@ -114,9 +121,21 @@ func (r *router) getRoutes(method string) []*node {
 func (r *router) handle(c *Context) {
 	n, params := r.getRoute(c.Method, c.Path)
 
-	if n != nil {
+	if fixed, ok := r.redirectPath(c, n); ok {
+		c.handlers = append(c.handlers, func(c *Context) {
+			code := http.StatusMovedPermanently
+			if c.Method != http.MethodGet {
+				// 308 会保留请求方法和 body
+				code = http.StatusPermanentRedirect
+			}
+			u := *c.Req.URL
+			u.Path, u.RawPath = fixed, ""
+			c.Redirect(code, u.String())
+		})
+	} else if n != nil {
 		key := c.Method + "-" + n.pattern
 		c.Params = params
+		c.fullPath = n.pattern
 		c.handlers = append(c.handlers, r.handlers[key])
 	} else {
 		c.handlers = append(c.handlers, func(c *Context) {
@@ -125,4 +144,62 @@ func (r *router) handle(c *Context) {
 	}
 	// 开始执行
 	c.Next()
-}
\ No newline at end of file
+}
+
+// redirectPath 根据 Engine 的 RedirectFixedPath 和 RedirectTrailingSlash 配置，
+// 计算需要重定向到的规范路径，n 为精确匹配到的节点
+func (r *router) redirectPath(c *Context, n *node) (string, bool) {
+	engine := c.engine
+	if engine == nil || c.Method == http.MethodConnect || c.Path == "/" {
+		return "", false
+	}
+	// 已精确匹配且路径是规范的，就不需要再做忽略大小写的查找
+	if engine.RedirectFixedPath && (n == nil || CleanPath(c.Path) != c.Path) {
+		fixed, ok := r.findCaseInsensitivePath(c.Method, CleanPath(c.Path), engine.RedirectTrailingSlash)
+		if ok && fixed != c.Path {
+			return fixed, true
+		}
+	}
+	if engine.RedirectTrailingSlash && n != nil {
+		if fixed := fixTrailingSlash(c.Path, n.pattern); fixed != c.Path {
+			return fixed, true
+		}
+	}
+	return "", false
+}
+
+// findCaseInsensitivePath 忽略大小写查找路由，返回按注册路由修正大小写后的路径
+// fixSlash 为 true 时，末尾的 '/' 也修正为与注册的路由一致
+func (r *router) findCaseInsensitivePath(method string, path string, fixSlash bool) (string, bool) {
+	root, ok := r.roots[method]
+	if !ok {
+		return "", false
+	}
+	n, parts := root.searchFold(parsePattern(path), 0, nil)
+	if n == nil {
+		return "", false
+	}
+	fixed := "/" + strings.Join(parts, "/")
+	if fixSlash {
+		fixed = fixTrailingSlash(fixed, n.pattern)
+	} else if fixed != "/" && strings.HasSuffix(path, "/") {
+		fixed += "/"
+	}
+	return fixed, true
+}
+
+// fixTrailingSlash 让 path 末尾的 '/' 与路由 pattern 保持一致，通配(*)路由不处理
+func fixTrailingSlash(path string, pattern string) string {
+	if strings.Contains(pattern, "*") || path == "/" {
+		return path
+	}
+	want := pattern != "/" && strings.HasSuffix(pattern, "/")
+	has := strings.HasSuffix(path, "/")
+	switch {
+	case want && !has:
+		return path + "/"
+	case !want && has:
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
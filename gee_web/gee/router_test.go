@@ -2,6 +2,8 @@ package gee
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
@@ -72,3 +74,93 @@ func TestGetRoutes(t *testing.T) {
 		t.Fatal("the number of routes shoule be 4")
 	}
 }
+
+func TestCleanPath(t *testing.T) {
+	cases := map[string]string{
+		"":              "/",
+		"p/go":          "/p/go",
+		"/p//go/":       "/p/go/",
+		"/p/./go/../go": "/p/go",
+		"/../p":         "/p",
+		"//":            "/",
+	}
+	for in, expected := range cases {
+		if got := CleanPath(in); got != expected {
+			t.Errorf("CleanPath(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
+
+func TestRedirectTrailingSlash(t *testing.T) {
+	r := New()
+	r.RedirectTrailingSlash = true
+	handler := func(c *Context) { c.String(http.StatusOK, "%s", c.FullPath()) }
+	r.GET("/p/go", handler)
+	r.GET("/dir/", handler)
+	r.GET("/Hello/:name", handler)
+	r.GET("/assets/*filepath", handler)
+	r.POST("/p/go", handler)
+	cases := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{"GET", "/p/go", http.StatusOK, ""},
+		{"GET", "/p/go/", http.StatusMovedPermanently, "/p/go"},
+		{"GET", "/dir", http.StatusMovedPermanently, "/dir/"},
+		{"GET", "/dir?a=1", http.StatusMovedPermanently, "/dir/?a=1"},
+		{"POST", "/p/go/", http.StatusPermanentRedirect, "/p/go"},
+		{"GET", "/assets/css/", http.StatusOK, ""},
+		// 没有开启 RedirectFixedPath 时保持原有的行为
+		{"GET", "/p//go", http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.code || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: got %d %q, expected %d %q", tc.method, tc.path, w.Code, w.Header().Get("Location"), tc.code, tc.location)
+		}
+	}
+}
+
+func TestRedirectFixedPath(t *testing.T) {
+	r := New()
+	r.RedirectTrailingSlash = true
+	r.RedirectFixedPath = true
+	handler := func(c *Context) { c.String(http.StatusOK, "%s", c.FullPath()) }
+	r.GET("/p/go", handler)
+	r.GET("/dir/", handler)
+	r.GET("/Hello/:name", handler)
+	r.GET("/assets/*filepath", handler)
+	r.POST("/p/go", handler)
+	cases := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/p//go", http.StatusMovedPermanently, "/p/go"},
+		{"/p//go/", http.StatusMovedPermanently, "/p/go"},
+		{"/P/GO", http.StatusMovedPermanently, "/p/go"},
+		{"/../x/../p/Go", http.StatusMovedPermanently, "/p/go"},
+		{"/DIR", http.StatusMovedPermanently, "/dir/"},
+		{"/hello/Geektutu", http.StatusMovedPermanently, "/Hello/Geektutu"},
+		{"/ASSETS/css//a.css", http.StatusMovedPermanently, "/assets/css/a.css"},
+		{"/Hello/geektutu", http.StatusOK, ""},
+		{"/nothing", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != tc.code || w.Header().Get("Location") != tc.location {
+			t.Errorf("GET %s: got %d %q, expected %d %q", tc.path, w.Code, w.Header().Get("Location"), tc.code, tc.location)
+		}
+	}
+
+	// 不修正末尾 '/' 时保留请求中的形式
+	r.RedirectTrailingSlash = false
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/P//GO/", nil))
+	if w.Header().Get("Location") != "/p/go/" {
+		t.Errorf("trailing slash should be kept, got %q", w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,130 @@
+/*
+除 Run 之外的几种启动方式：
+RunH2C 在明文 TCP 上同时支持 HTTP/1.1 和 HTTP/2 (h2c)，用于部署在内网负载均衡之后；
+RunTLS 启用 HTTPS（自动协商 HTTP/2），证书和私钥文件变化时自动重新加载，无需重启。
+*/
+
+package gee
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloadInterval 检查证书文件是否变化的最小间隔
+var certReloadInterval = 10 * time.Second
+
+// RunH2C 启动支持 HTTP/2 明文(h2c)的服务
+func (engine *Engine) RunH2C(addr string) error {
+	engine.debugPrintRouteTable()
+	debugPrint("Listening and serving HTTP/2 cleartext on %s", addr)
+	return engine.newH2CServer(addr).ListenAndServe()
+}
+
+// RunTLS 启动 HTTPS 服务，证书文件更新后新的连接会使用新证书
+func (engine *Engine) RunTLS(addr, certFile, keyFile string) error {
+	server, err := engine.newTLSServer(addr, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	engine.debugPrintRouteTable()
+	debugPrint("Listening and serving HTTPS on %s", addr)
+	// 证书由 TLSConfig.GetCertificate 提供，这里不需要再传文件路径
+	return server.ListenAndServeTLS("", "")
+}
+
+func (engine *Engine) newH2CServer(addr string) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Server{Addr: addr, Handler: engine, Protocols: protocols}
+}
+
+func (engine *Engine) newTLSServer(addr, certFile, keyFile string) (*http.Server, error) {
+	loader, err := newCertLoader(certFile, keyFile, certReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:    addr,
+		Handler: engine,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: loader.GetCertificate,
+		},
+	}, nil
+}
+
+// certLoader 缓存证书，并在文件修改时间变化时重新加载
+type certLoader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+func newCertLoader(certFile, keyFile string, interval time.Duration) (*certLoader, error) {
+	l := &certLoader{certFile: certFile, keyFile: keyFile, interval: interval}
+	certMod, keyMod, err := l.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err = l.load(certMod, keyMod); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetCertificate 用于 tls.Config，每次握手时调用
+func (l *certLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastCheck) >= l.interval {
+		l.lastCheck = now
+		certMod, keyMod, err := l.modTimes()
+		if err != nil {
+			log.Printf("gee: stat certificate failed, keep using the old one: %v", err)
+		} else if !certMod.Equal(l.certMod) || !keyMod.Equal(l.keyMod) {
+			// 证书和私钥可能没有同时写完，加载失败时继续使用旧证书，下次检查时重试
+			if err = l.load(certMod, keyMod); err != nil {
+				log.Printf("gee: reload certificate failed, keep using the old one: %v", err)
+			} else {
+				log.Printf("gee: certificate %s reloaded", l.certFile)
+			}
+		}
+	}
+	return l.cert, nil
+}
+
+func (l *certLoader) load(certMod, keyMod time.Time) error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return err
+	}
+	l.cert = &cert
+	l.certMod, l.keyMod = certMod, keyMod
+	return nil
+}
+
+func (l *certLoader) modTimes() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(l.certFile)
+	if err != nil {
+		return
+	}
+	keyInfo, err := os.Stat(l.keyFile)
+	if err != nil {
+		return
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,304 @@
+/*
+会话(Session)管理：
+cookie 中只保存经过 HMAC 签名的 session id，会话数据保存在可替换的 SessionStore 中。
+签名密钥支持轮换：第一个密钥用于签名，所有密钥都可用于校验。
+*/
+
+package gee
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSessionKey 会话在 Context.Keys 中的键
+const DefaultSessionKey = "gee/session"
+
+var errInvalidSignature = errors.New("gee: invalid session signature")
+
+// SessionStore 会话数据的存储后端
+type SessionStore interface {
+	// Load 读取会话数据，不存在或已过期时返回 nil, nil
+	Load(id string) (map[string]interface{}, error)
+	// Save 保存会话数据，ttl <= 0 表示不过期
+	Save(id string, values map[string]interface{}, ttl time.Duration) error
+	// Delete 删除会话数据
+	Delete(id string) error
+}
+
+// SessionOptions 会话 cookie 的属性
+type SessionOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   int // 单位秒，<0 表示立即删除，=0 表示浏览器会话 cookie
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+var defaultSessionOptions = SessionOptions{
+	Path:     "/",
+	MaxAge:   86400 * 7,
+	HttpOnly: true,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// Session 一次请求中的会话
+type Session struct {
+	name    string
+	id      string
+	values  map[string]interface{}
+	options SessionOptions
+	store   SessionStore
+	signer  *signer
+	c       *Context
+	loaded  bool
+	isNew   bool
+}
+
+// Sessions 会话中间件，keys 为签名密钥，第一个用于签名，其余仅用于校验旧 cookie
+func Sessions(name string, store SessionStore, keys ...[]byte) HandlerFunc {
+	if len(keys) == 0 {
+		panic("gee: Sessions requires at least one signing key")
+	}
+	s := &signer{keys: keys}
+	return func(c *Context) {
+		c.Set(DefaultSessionKey, &Session{
+			name:    name,
+			options: defaultSessionOptions,
+			store:   store,
+			signer:  s,
+			c:       c,
+		})
+		c.Next()
+	}
+}
+
+// DefaultSession 获得 Sessions 中间件放入 context 的会话
+func DefaultSession(c *Context) *Session {
+	v, ok := c.Get(DefaultSessionKey)
+	if !ok {
+		panic("gee: Sessions middleware is not registered")
+	}
+	return v.(*Session)
+}
+
+// load 第一次访问时从 cookie 和 store 中读取会话
+func (s *Session) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.isNew = true
+	s.values = make(map[string]interface{})
+
+	raw, err := s.c.Cookie(s.name)
+	if err != nil || raw == "" {
+		return
+	}
+	// 使用旧密钥签名的 cookie 同样有效，下次 Save 时会用新密钥重新签名
+	id, err := s.signer.verify(s.name, raw)
+	if err != nil {
+		return
+	}
+	values, err := s.store.Load(id)
+	if err != nil {
+		log.Printf("gee: load session failed: %v", err)
+		return
+	}
+	if values == nil {
+		return
+	}
+	s.id = id
+	s.values = values
+	s.isNew = false
+}
+
+// ID 会话 id，新会话在 Save 之前为空
+func (s *Session) ID() string {
+	s.load()
+	return s.id
+}
+
+// IsNew 是否为本次请求新建的会话
+func (s *Session) IsNew() bool {
+	s.load()
+	return s.isNew
+}
+
+// Get 读取会话中的值
+func (s *Session) Get(key string) interface{} {
+	s.load()
+	return s.values[key]
+}
+
+// Set 写入会话中的值，需要调用 Save 才会生效
+func (s *Session) Set(key string, value interface{}) {
+	s.load()
+	s.values[key] = value
+}
+
+// Delete 删除会话中的值
+func (s *Session) Delete(key string) {
+	s.load()
+	delete(s.values, key)
+}
+
+// Clear 清空会话中的所有值
+func (s *Session) Clear() {
+	s.load()
+	s.values = make(map[string]interface{})
+}
+
+// Options 修改会话 cookie 的属性
+func (s *Session) Options(options SessionOptions) {
+	s.options = options
+}
+
+// Save 保存会话数据并写入 cookie，需要在写入响应之前调用
+func (s *Session) Save() error {
+	s.load()
+	if s.options.MaxAge < 0 {
+		return s.Destroy()
+	}
+	if s.id == "" {
+		id, err := randomToken()
+		if err != nil {
+			return err
+		}
+		s.id = id
+	}
+	ttl := time.Duration(s.options.MaxAge) * time.Second
+	if err := s.store.Save(s.id, s.values, ttl); err != nil {
+		return err
+	}
+	s.writeCookie(s.signer.sign(s.name, s.id), s.options.MaxAge)
+	return nil
+}
+
+// Destroy 删除会话数据并让浏览器删除 cookie
+func (s *Session) Destroy() error {
+	s.load()
+	if s.id != "" {
+		if err := s.store.Delete(s.id); err != nil {
+			return err
+		}
+	}
+	s.id = ""
+	s.values = make(map[string]interface{})
+	s.writeCookie("", -1)
+	return nil
+}
+
+func (s *Session) writeCookie(value string, maxAge int) {
+	s.c.SetSameSite(s.options.SameSite)
+	s.c.SetCookie(s.name, value, maxAge, s.options.Path, s.options.Domain, s.options.Secure, s.options.HttpOnly)
+}
+
+// randomToken 生成 32 字节的随机字符串，用于 session id 等
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// signer 使用 HMAC-SHA256 对 cookie 值签名
+type signer struct {
+	keys [][]byte
+}
+
+func (sg *signer) mac(key []byte, name, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "|" + value))
+	return h.Sum(nil)
+}
+
+// sign 使用第一个密钥签名，格式为 value.signature
+func (sg *signer) sign(name, value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(sg.mac(sg.keys[0], name, value))
+}
+
+// verify 依次使用所有密钥校验，任意一个通过即可
+func (sg *signer) verify(name, signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", errInvalidSignature
+	}
+	value := signed[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", errInvalidSignature
+	}
+	for _, key := range sg.keys {
+		if hmac.Equal(sig, sg.mac(key, name, value)) {
+			return value, nil
+		}
+	}
+	return "", errInvalidSignature
+}
+
+// MemoryStore 基于内存的 SessionStore，适用于单实例部署
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memorySession
+}
+
+type memorySession struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// NewMemoryStore 是 MemoryStore 的构造函数
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memorySession)}
+}
+
+func (m *MemoryStore) Load(id string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ms, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if !ms.expires.IsZero() && time.Now().After(ms.expires) {
+		delete(m.sessions, id)
+		return nil, nil
+	}
+	return copyValues(ms.values), nil
+}
+
+func (m *MemoryStore) Save(id string, values map[string]interface{}, ttl time.Duration) error {
+	ms := memorySession{values: copyValues(values)}
+	if ttl > 0 {
+		ms.expires = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	m.sessions[id] = ms
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		cp[k] = v
+	}
+	return cp
+}
@@ -0,0 +1,121 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func doRequest(r http.Handler, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSession(t *testing.T) {
+	r := New()
+	r.Use(Sessions("gee_session", NewMemoryStore(), []byte("secret")))
+	r.GET("/set", func(c *Context) {
+		s := DefaultSession(c)
+		s.Set("user", c.Query("user"))
+		if err := s.Save(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/get", func(c *Context) {
+		user, _ := DefaultSession(c).Get("user").(string)
+		c.String(http.StatusOK, "%s", user)
+	})
+
+	w := doRequest(r, "/set?user=geektutu")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "gee_session" || !cookies[0].HttpOnly {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+	w = doRequest(r, "/get", cookies...)
+	if w.Body.String() != "geektutu" {
+		t.Fatalf("session value should be geektutu, got %q", w.Body.String())
+	}
+
+	// 篡改后的 cookie 开始新的 session
+	cookie := *cookies[0]
+	cookie.Value = cookie.Value[:len(cookie.Value)-2] + "xx"
+	if body := doRequest(r, "/get", &cookie).Body.String(); body != "" {
+		t.Fatalf("tampered cookie should start a new session, got %q", body)
+	}
+}
+
+func TestSessionKeyRotation(t *testing.T) {
+	store := NewMemoryStore()
+	old := New()
+	old.Use(Sessions("gee_session", store, []byte("old-key")))
+	old.GET("/set", func(c *Context) {
+		s := DefaultSession(c)
+		s.Set("user", c.Query("user"))
+		if err := s.Save(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	cookie := doRequest(old, "/set?user=geektutu").Result().Cookies()[0]
+
+	// 新密钥签名，旧密钥仍可校验
+	rotated := New()
+	rotated.Use(Sessions("gee_session", store, []byte("new-key"), []byte("old-key")))
+	rotated.GET("/get", func(c *Context) {
+		s := DefaultSession(c)
+		user, _ := s.Get("user").(string)
+		if err := s.Save(); err != nil {
+			t.Fatal(err)
+		}
+		c.String(http.StatusOK, "%s", user)
+	})
+	w := doRequest(rotated, "/get", cookie)
+	if w.Body.String() != "geektutu" {
+		t.Fatalf("cookie signed by old key should be accepted, got %q", w.Body.String())
+	}
+	resigned := w.Result().Cookies()[0]
+	if resigned.Value == cookie.Value {
+		t.Fatal("cookie should be re-signed with the new key")
+	}
+
+	// 移除旧密钥后，旧 cookie 失效而重新签名的 cookie 仍有效
+	fresh := New()
+	fresh.Use(Sessions("gee_session", store, []byte("new-key")))
+	fresh.GET("/get", func(c *Context) {
+		user, _ := DefaultSession(c).Get("user").(string)
+		c.String(http.StatusOK, "%s", user)
+	})
+	if body := doRequest(fresh, "/get", cookie).Body.String(); body != "" {
+		t.Fatalf("cookie signed by retired key should be rejected, got %q", body)
+	}
+	if body := doRequest(fresh, "/get", resigned).Body.String(); body != "geektutu" {
+		t.Fatalf("re-signed cookie should be accepted, got %q", body)
+	}
+}
+
+func TestContextCookie(t *testing.T) {
+	r := New()
+	r.GET("/cookie", func(c *Context) {
+		v, err := c.Cookie("lang")
+		if err != nil {
+			c.String(http.StatusBadRequest, "%s", err.Error())
+			return
+		}
+		c.SetCookie("echo", v, 60, "", "", false, true)
+		c.String(http.StatusOK, "%s", v)
+	})
+	w := doRequest(r, "/cookie", &http.Cookie{Name: "lang", Value: "go%20lang"})
+	if w.Body.String() != "go lang" {
+		t.Fatalf("cookie value should be unescaped, got %q", w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Path != "/" || cookies[0].MaxAge != 60 {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+}
@@ -72,6 +72,51 @@ func (n *node) search(parts []string, height int) *node {
 	return nil
 }
 
+// searchFold 与 search 相同，但静态部分忽略大小写，
+// 同时返回按注册的路由修正大小写后的各段，优先精确匹配，其次忽略大小写匹配，最后模糊匹配
+func (n *node) searchFold(parts []string, height int, fixed []string) (*node, []string) {
+	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+		if n.pattern == "" {
+			return nil, nil
+		}
+		return n, fixed
+	}
+
+	part := parts[height]
+	candidates := make([]*node, 0, len(n.children))
+	for _, child := range n.children {
+		if !child.isWild && child.part == part {
+			candidates = append(candidates, child)
+		}
+	}
+	for _, child := range n.children {
+		if !child.isWild && child.part != part && strings.EqualFold(child.part, part) {
+			candidates = append(candidates, child)
+		}
+	}
+	for _, child := range n.children {
+		if child.isWild {
+			candidates = append(candidates, child)
+		}
+	}
+
+	for _, child := range candidates {
+		segment := child.part
+		if child.isWild {
+			segment = part
+			if child.part[0] == '*' {
+				segment = strings.Join(parts[height:], "/")
+			}
+		}
+		// 使用三下标切片，避免不同分支共用底层数组
+		result, resultParts := child.searchFold(parts, height+1, append(fixed[:len(fixed):len(fixed)], segment))
+		if result != nil {
+			return result, resultParts
+		}
+	}
+	return nil, nil
+}
+
 func (n *node) travel(list *([]*node)) {
 	if n.pattern != "" {
 		*list = append(*list, n)
@@ -81,6 +126,24 @@ func (n *node) travel(list *([]*node)) {
 	}
 }
 
+// wildConflict 插入 parts 时如果某一层会复用名字不同的通配节点（例如 :name 与 :id、b 与 :name），
+// 返回该通配节点对应的路由前缀，否则返回空字符串
+func (n *node) wildConflict(parts []string) string {
+	cur := n
+	for i, part := range parts {
+		child := cur.matchChild(part)
+		if child == nil {
+			return ""
+		}
+		if child.isWild && child.part != part {
+			prefix := append(append([]string{}, parts[:i]...), child.part)
+			return "/" + strings.Join(prefix, "/")
+		}
+		cur = child
+	}
+	return ""
+}
+
 // matchChild 第一个匹配成功的节点，用于插入
 func (n *node) matchChild(part string) *node {
 	for _, child := range n.children {
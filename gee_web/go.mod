@@ -1,5 +1,7 @@
 module gee_web
 
+go 1.24
+
 require gee v0.0.0
 
-replace gee => ./gee
\ No newline at end of file
+replace gee => ./gee
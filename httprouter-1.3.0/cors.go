@@ -0,0 +1,155 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is a Cross-Origin Resource Sharing policy.
+// If it is set as Router.CORS, the router answers preflight requests on its
+// automatic OPTIONS path and adds the CORS headers to the responses of
+// cross-origin requests to registered routes.
+// The allowed methods of a path are taken from the registered routes.
+//
+// CORS 跨域策略，由 Router 在自动处理 OPTIONS 预检请求时使用
+type CORS struct {
+	// Origins which may access the resources, e.g. "https://example.com".
+	// "*" allows any origin, but can not be combined with AllowCredentials.
+	AllowOrigins []string
+
+	// Optional function which is called for origins not listed in
+	// AllowOrigins. The origin is allowed if it returns true.
+	AllowOriginFunc func(origin string) bool
+
+	// Request headers which may be used in the actual request.
+	// If empty, the headers requested by the preflight are allowed.
+	AllowHeaders []string
+
+	// Response headers which the client may read.
+	ExposeHeaders []string
+
+	// Whether the request may include credentials like cookies.
+	// The allowed origins must then be listed in AllowOrigins or accepted by
+	// AllowOriginFunc; "*" is rejected, like browsers do.
+	AllowCredentials bool
+
+	// How long the result of a preflight request may be cached.
+	// Zero omits the header.
+	MaxAge time.Duration
+}
+
+// NewCORS returns a copy of the given policy, to be set as Router.CORS.
+// It panics if the policy allows credentials for any origin ("*"), which
+// would let every website make authenticated requests.
+//
+// NewCORS 校验跨域策略，"*" 与 AllowCredentials 同时使用时 panic
+func NewCORS(policy CORS) *CORS {
+	if policy.AllowCredentials && policy.allowsAny() {
+		panic("CORS: AllowOrigins must not contain '*' if AllowCredentials is set, list the origins or use AllowOriginFunc")
+	}
+	return &policy
+}
+
+// allowsAny reports whether AllowOrigins contains "*".
+func (c *CORS) allowsAny() bool {
+	for _, o := range c.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header for
+// the given origin, or an empty string if the origin is not allowed.
+func (c *CORS) allowOrigin(origin string) string {
+	for _, o := range c.AllowOrigins {
+		if o == "*" {
+			if c.AllowCredentials {
+				// rejected by NewCORS; a policy set without it fails
+				// closed instead of allowing credentials for any origin
+				continue
+			}
+			return "*"
+		}
+		if o == origin {
+			return origin
+		}
+	}
+	if c.AllowOriginFunc != nil && c.AllowOriginFunc(origin) {
+		return origin
+	}
+	return ""
+}
+
+// setHeaders adds the headers for an actual cross-origin request.
+// It reports whether the origin of the request is allowed.
+func (c *CORS) setHeaders(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	allow := c.allowOrigin(origin)
+	if allow == "" {
+		return false
+	}
+	header.Set("Access-Control-Allow-Origin", allow)
+	if c.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(c.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+	}
+	return true
+}
+
+// setPreflightHeaders adds the headers answering a preflight request for a
+// path which allows the given methods (as returned by Router.allowed).
+func (c *CORS) setPreflightHeaders(w http.ResponseWriter, req *http.Request, allow string) {
+	method := req.Header.Get("Access-Control-Request-Method")
+	if method == "" || !containsMethod(allow, method) {
+		return
+	}
+	if !c.setHeaders(w, req) {
+		return
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", allow)
+	if len(c.AllowHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(c.AllowHeaders, ", "))
+	} else if h := req.Header.Get("Access-Control-Request-Headers"); h != "" {
+		header.Set("Access-Control-Allow-Headers", h)
+	}
+	if c.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+}
+
+// containsMethod reports whether the comma separated list of methods contains
+// the given method.
+func containsMethod(allow, method string) bool {
+	for allow != "" {
+		var m string
+		if i := strings.IndexByte(allow, ','); i >= 0 {
+			m, allow = allow[:i], allow[i+1:]
+		} else {
+			m, allow = allow, ""
+		}
+		if strings.TrimSpace(m) == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func preflight(router *Router, origin, method, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/items", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := New()
+	router.CORS = &CORS{
+		AllowOrigins: []string{"https://example.com"},
+		MaxAge:       10 * time.Minute,
+	}
+	router.GET("/items", func(w http.ResponseWriter, _ *http.Request, _ Params) {
+		w.Write([]byte("items"))
+	})
+	router.POST("/items", func(http.ResponseWriter, *http.Request, Params) {})
+
+	w := preflight(router, "https://example.com", http.MethodPost, "X-Token")
+	header := w.Header()
+	if w.Code != http.StatusOK {
+		t.Fatalf("preflight failed: Code=%d", w.Code)
+	}
+	for key, want := range map[string]string{
+		"Allow":                        "GET, OPTIONS, POST",
+		"Access-Control-Allow-Origin":  "https://example.com",
+		"Access-Control-Allow-Methods": "GET, OPTIONS, POST",
+		"Access-Control-Allow-Headers": "X-Token",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if got := header.Get(key); got != want {
+			t.Errorf("wrong %s header: %q, want %q", key, got, want)
+		}
+	}
+	if header.Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("credentials allowed without AllowCredentials")
+	}
+
+	// origin not allowed
+	w = preflight(router, "https://evil.example", http.MethodPost, "")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers set for a disallowed origin")
+	}
+
+	// method not allowed for the path
+	w = preflight(router, "https://example.com", http.MethodDelete, "")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers set for a disallowed method")
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	router := New()
+	router.CORS = NewCORS(CORS{
+		AllowOrigins:     []string{"https://app.example"},
+		AllowHeaders:     []string{"Content-Type", "X-Token"},
+		AllowCredentials: true,
+	})
+	router.GET("/items", fakeHandler("items"))
+
+	w := preflight(router, "https://app.example", http.MethodGet, "X-Other")
+	header := w.Header()
+	if got := header.Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("origin must be echoed with credentials, got %q", got)
+	}
+	if header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("missing Access-Control-Allow-Credentials")
+	}
+	if got := header.Get("Access-Control-Allow-Headers"); got != "Content-Type, X-Token" {
+		t.Errorf("wrong Access-Control-Allow-Headers: %q", got)
+	}
+}
+
+func TestCORSWildcardCredentials(t *testing.T) {
+	policy := CORS{
+		AllowOrigins:     []string{"*"},
+		AllowCredentials: true,
+	}
+	recv := catchPanic(func() {
+		NewCORS(policy)
+	})
+	if recv == nil {
+		t.Fatal("wildcard origin with credentials did not panic")
+	}
+
+	// a policy set without NewCORS does not allow any origin
+	router := New()
+	router.CORS = &policy
+	router.GET("/items", fakeHandler("items"))
+	w := preflight(router, "https://evil.example", http.MethodGet, "")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("wildcard origin allowed with credentials: %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("credentials allowed for a wildcard origin")
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	router := New()
+	router.CORS = &CORS{
+		AllowOriginFunc: func(origin string) bool { return origin == "https://example.com" },
+		ExposeHeaders:   []string{"X-Total"},
+	}
+	router.GET("/items", func(w http.ResponseWriter, _ *http.Request, _ Params) {
+		w.Write([]byte("items"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Origin", "https://example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "items" {
+		t.Fatalf("handle was not called: %q", w.Body.String())
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("wrong Access-Control-Allow-Origin: %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total" {
+		t.Errorf("wrong Access-Control-Expose-Headers: %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("wrong Vary header: %q", got)
+	}
+
+	// same-origin requests are left alone
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("unexpected CORS headers without Origin: %v", w.Header())
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of routes returned by Router.Suggest.
+const maxSuggestions = 3
+
+// Suggestion is a registered route close to a path which could not be routed.
+type Suggestion struct {
+	// The corrected request path, or the path of the registered route, e.g.
+	// "/users/:id"
+	Path string `json:"path"`
+
+	// Methods with a handle for Path, sorted
+	Methods []string `json:"methods"`
+}
+
+// problem is the JSON body of the diagnostic 404 and 405 responses.
+type problem struct {
+	Type           string       `json:"type"`
+	Title          string       `json:"title"`
+	Status         int          `json:"status"`
+	Detail         string       `json:"detail"`
+	Instance       string       `json:"instance"`
+	AllowedMethods []string     `json:"allowed_methods,omitempty"`
+	Suggestions    []Suggestion `json:"suggestions,omitempty"`
+}
+
+// Suggest returns up to three registered routes close to the given request
+// path, e.g. for helpful 404 pages. The first one is the case-insensitive
+// and cleaned up version of the path, if the request method has a handle for
+// it. The others are the routes with the smallest edit distance to the path,
+// where path parameters match any value. Routes whose static parts differ in
+// more than about a third of their bytes are never suggested.
+//
+// Suggest 根据前缀树计算与请求路径最相近的已注册路由
+func (r *Router) Suggest(method, path string) []Suggestion {
+	ts := r.getTrees()
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+
+	if root := ts.trees[method]; root != nil {
+		if fixed, found := root.findCaseInsensitivePath(CleanPath(path), true); found && string(fixed) != path {
+			p := string(fixed)
+			suggestions = append(suggestions, Suggestion{p, ts.methods(p)})
+			if leaf, _, _ := root.getLeaf(p, nil); leaf != nil {
+				seen[leaf.fullPath] = true
+			}
+		}
+	}
+
+	type candidate struct {
+		path     string
+		distance int
+	}
+	var candidates []candidate
+	for _, route := range r.Routes() {
+		if seen[route.Path] {
+			continue
+		}
+		seen[route.Path] = true
+		d := levenshtein(strings.ToLower(path), strings.ToLower(instantiate(route.Path, path)))
+		if d > 0 && d <= staticLen(route.Path)/3+1 {
+			candidates = append(candidates, candidate{route.Path, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	for _, c := range candidates {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, Suggestion{c.path, ts.routeMethods(c.path)})
+	}
+	return suggestions
+}
+
+// writeProblem writes the diagnostic response for a request which could not
+// be routed. allow are the allowed methods as returned by Router.allowed.
+func (r *Router) writeProblem(w http.ResponseWriter, req *http.Request, code int, allow string) {
+	p := problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(code),
+		Status:   code,
+		Detail:   "no route for " + req.Method + " " + req.URL.Path,
+		Instance: req.URL.Path,
+	}
+	if allow != "" {
+		p.AllowedMethods = strings.Split(allow, ", ")
+	}
+	if code == http.StatusNotFound {
+		p.Suggestions = r.Suggest(req.Method, req.URL.Path)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(p)
+}
+
+// methods returns the sorted methods which have a handle for the request
+// path.
+func (ts *treeSet) methods(path string) []string {
+	var methods []string
+	for method, root := range ts.trees {
+		if handle, _, _ := root.getValue(path); handle != nil {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// routeMethods returns the sorted methods for which a route with the given
+// registered path exists.
+func (ts *treeSet) routeMethods(fullPath string) []string {
+	var methods []string
+	for method, root := range ts.trees {
+		if root.find(fullPath) != nil {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// instantiate fills the params of a route path with the segments of the
+// request path at the same position, so that only the static parts of the
+// route count for the edit distance.
+func instantiate(route, path string) string {
+	routeSegs := strings.Split(route, "/")
+	pathSegs := strings.Split(path, "/")
+	for i, seg := range routeSegs {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*' && seg[0] != '{') {
+			continue
+		}
+		if i >= len(pathSegs) {
+			break
+		}
+		if seg[0] == '*' {
+			routeSegs = append(routeSegs[:i], pathSegs[i:]...)
+			break
+		}
+		routeSegs[i] = pathSegs[i]
+	}
+	return strings.Join(routeSegs, "/")
+}
+
+// staticLen returns the number of bytes of a route path which do not belong
+// to a param, which bounds how many edits still make a route similar.
+func staticLen(route string) int {
+	n := 0
+	for _, seg := range strings.Split(route, "/") {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*' && seg[0] != '{') {
+			n += len(seg) + 1
+		}
+	}
+	return n
+}
+
+// levenshtein returns the edit distance of a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
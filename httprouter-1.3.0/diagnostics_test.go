@@ -0,0 +1,118 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func serveProblem(t *testing.T, router *Router, method, path string) (*httptest.ResponseRecorder, problem) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(method, path, nil)
+	router.ServeHTTP(w, r)
+
+	var p problem
+	if w.Header().Get("Content-Type") == "application/problem+json" {
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("invalid problem body %q: %s", w.Body.String(), err)
+		}
+	}
+	return w, p
+}
+
+func TestDiagnosticsNotFound(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request, Params) {}
+	router := New()
+	router.Diagnostics = true
+	router.RedirectFixedPath = false
+	router.GET("/users", h)
+	router.POST("/users", h)
+	router.GET("/users/:id", h)
+	router.GET("/orders/:id/items", h)
+	router.GET("/static/*filepath", h)
+
+	w, p := serveProblem(t, router, http.MethodGet, "/USERS/42")
+	if w.Code != http.StatusNotFound || p.Status != http.StatusNotFound {
+		t.Fatalf("unexpected status %d, problem %+v", w.Code, p)
+	}
+	if p.Type != "about:blank" || p.Title != "Not Found" || p.Instance != "/USERS/42" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	want := []Suggestion{{"/users/42", []string{"GET"}}}
+	if len(p.Suggestions) == 0 || !reflect.DeepEqual(p.Suggestions[:1], want) {
+		t.Errorf("suggestions: got %+v, want %+v first", p.Suggestions, want)
+	}
+
+	_, p = serveProblem(t, router, http.MethodGet, "/order/7/items")
+	want = []Suggestion{{"/orders/:id/items", []string{"GET"}}}
+	if !reflect.DeepEqual(p.Suggestions, want) {
+		t.Errorf("suggestions: got %+v, want %+v", p.Suggestions, want)
+	}
+
+	_, p = serveProblem(t, router, http.MethodGet, "/something/completely/different")
+	if len(p.Suggestions) != 0 {
+		t.Errorf("unexpected suggestions %+v", p.Suggestions)
+	}
+}
+
+func TestDiagnosticsMethodNotAllowed(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request, Params) {}
+	router := New()
+	router.Diagnostics = true
+	router.GET("/users", h)
+	router.POST("/users", h)
+
+	w, p := serveProblem(t, router, http.MethodDelete, "/users")
+	if w.Code != http.StatusMethodNotAllowed || p.Status != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status %d, problem %+v", w.Code, p)
+	}
+	want := []string{"GET", "OPTIONS", "POST"}
+	if !reflect.DeepEqual(p.AllowedMethods, want) {
+		t.Errorf("allowed methods: got %v, want %v", p.AllowedMethods, want)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, OPTIONS, POST" {
+		t.Errorf("unexpected Allow header %q", allow)
+	}
+}
+
+func TestDiagnosticsDisabled(t *testing.T) {
+	router := New()
+	router.GET("/users", fakeHandler("users"))
+
+	w, _ := serveProblem(t, router, http.MethodGet, "/userz")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") == "application/problem+json" {
+		t.Errorf("diagnostics must be disabled: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// custom handlers take precedence
+	router.Diagnostics = true
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	if w, _ = serveProblem(t, router, http.MethodGet, "/userz"); w.Code != http.StatusTeapot {
+		t.Errorf("custom NotFound handler was not used: %d", w.Code)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"/users", "/user", 1},
+	}
+	for _, test := range tests {
+		if d := levenshtein(test.a, test.b); d != test.d {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, d, test.d)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps a Handle to run code before and/or after it, e.g. for
+// logging or authentication.
+//
+// Middleware 中间件，接收一个 Handle 并返回包装后的 Handle
+type Middleware func(Handle) Handle
+
+// Group is a set of routes sharing a path prefix and a middleware chain.
+// Routes registered through a Group are inserted into the Router's trees like
+// any other route, the tree stays the single source of truth.
+// The middleware chain is composed once at registration time, so serving a
+// request through a Group adds no allocations.
+//
+// Group 路由组，注册时拼接前缀并包装中间件，最终仍然调用 Router.Handle
+type Group struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group returns a new Group. All routes registered through it are prefixed
+// with path and wrapped with the given middlewares.
+// The path must begin with '/', a trailing '/' is ignored.
+func (r *Router) Group(path string, middlewares ...Middleware) *Group {
+	return &Group{
+		router:      r,
+		prefix:      cleanGroupPrefix(path),
+		middlewares: append([]Middleware(nil), middlewares...),
+	}
+}
+
+// Group returns a nested Group. Its prefix is appended to the parent's prefix
+// and its middlewares run after the parent's middlewares.
+// Middlewares added to the parent later on do not affect the nested Group.
+func (g *Group) Group(path string, middlewares ...Middleware) *Group {
+	combined := make([]Middleware, 0, len(g.middlewares)+len(middlewares))
+	combined = append(combined, g.middlewares...)
+	combined = append(combined, middlewares...)
+	return &Group{
+		router:      g.router,
+		prefix:      g.prefix + cleanGroupPrefix(path),
+		middlewares: combined,
+	}
+}
+
+// Use appends middlewares to the Group. They only apply to routes which are
+// registered afterwards.
+func (g *Group) Use(middlewares ...Middleware) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+// Prefix returns the path prefix of the Group.
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Handle registers a new request handle with the given method and the path
+// relative to the Group's prefix, wrapped with the Group's middlewares.
+func (g *Group) Handle(method, path string, handle Handle) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	g.router.Handle(method, g.prefix+path, g.wrap(handle))
+}
+
+// HandleMethods registers the request handle for the path relative to the
+// Group's prefix and each of the given methods. The middleware chain is
+// composed once and shared by all methods.
+func (g *Group) HandleMethods(methods []string, path string, handle Handle) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	g.router.HandleMethods(methods, g.prefix+path, g.wrap(handle))
+}
+
+// Replace registers or swaps the request handle for the path relative to the
+// Group's prefix like Router.Replace, wrapped with the Group's middlewares.
+// Calling Router.Replace with the full path instead registers the bare
+// handle, without the middlewares.
+func (g *Group) Replace(method, path string, handle Handle) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	g.router.Replace(method, g.prefix+path, g.wrap(handle))
+}
+
+// wrap composes the middleware chain around handle. The first middleware
+// is the outermost one.
+func (g *Group) wrap(handle Handle) Handle {
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		handle = g.middlewares[i](handle)
+	}
+	return handle
+}
+
+// Handler is an adapter which allows the usage of an http.Handler as a
+// request handle within the Group.
+// The Params are available in the request context under ParamsKey.
+func (g *Group) Handler(method, path string, handler http.Handler) {
+	g.Handle(method, path, handlerToHandle(handler))
+}
+
+// HandlerFunc is an adapter which allows the usage of an http.HandlerFunc as
+// a request handle within the Group.
+func (g *Group) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	g.Handler(method, path, handler)
+}
+
+// GET is a shortcut for group.Handle(http.MethodGet, path, handle)
+func (g *Group) GET(path string, handle Handle) {
+	g.Handle(http.MethodGet, path, handle)
+}
+
+// HEAD is a shortcut for group.Handle(http.MethodHead, path, handle)
+func (g *Group) HEAD(path string, handle Handle) {
+	g.Handle(http.MethodHead, path, handle)
+}
+
+// OPTIONS is a shortcut for group.Handle(http.MethodOptions, path, handle)
+func (g *Group) OPTIONS(path string, handle Handle) {
+	g.Handle(http.MethodOptions, path, handle)
+}
+
+// POST is a shortcut for group.Handle(http.MethodPost, path, handle)
+func (g *Group) POST(path string, handle Handle) {
+	g.Handle(http.MethodPost, path, handle)
+}
+
+// PUT is a shortcut for group.Handle(http.MethodPut, path, handle)
+func (g *Group) PUT(path string, handle Handle) {
+	g.Handle(http.MethodPut, path, handle)
+}
+
+// PATCH is a shortcut for group.Handle(http.MethodPatch, path, handle)
+func (g *Group) PATCH(path string, handle Handle) {
+	g.Handle(http.MethodPatch, path, handle)
+}
+
+// DELETE is a shortcut for group.Handle(http.MethodDelete, path, handle)
+func (g *Group) DELETE(path string, handle Handle) {
+	g.Handle(http.MethodDelete, path, handle)
+}
+
+// cleanGroupPrefix validates a group prefix and strips the trailing slash,
+// so that "/api/" + "/users" does not become "/api//users".
+func cleanGroupPrefix(path string) string {
+	if len(path) < 1 || path[0] != '/' {
+		panic("group prefix must begin with '/' in path '" + path + "'")
+	}
+	return strings.TrimRight(path, "/")
+}
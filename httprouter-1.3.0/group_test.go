@@ -0,0 +1,143 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tagMiddleware(tag string, trace *[]string) Middleware {
+	return func(next Handle) Handle {
+		return func(w http.ResponseWriter, req *http.Request, ps Params) {
+			*trace = append(*trace, tag)
+			next(w, req, ps)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var trace []string
+	router := New()
+	api := router.Group("/api/", tagMiddleware("api", &trace))
+	v1 := api.Group("/v1", tagMiddleware("v1", &trace))
+	api.Use(tagMiddleware("late", &trace))
+
+	v1.GET("/users/:name", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		trace = append(trace, "handle:"+ps.ByName("name"))
+	})
+	api.POST("/ping", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		trace = append(trace, "ping")
+	})
+
+	if v1.Prefix() != "/api/v1" {
+		t.Fatalf("wrong prefix: %s", v1.Prefix())
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/gopher", nil))
+	if got := strings.Join(trace, ","); got != "api,v1,handle:gopher" {
+		t.Errorf("wrong middleware order: %s", got)
+	}
+
+	trace = nil
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/ping", nil))
+	if got := strings.Join(trace, ","); got != "api,late,ping" {
+		t.Errorf("wrong middleware order: %s", got)
+	}
+
+	// routes are stored in the router's tree
+	if handle, _, _ := router.Lookup(http.MethodGet, "/api/v1/users/gopher"); handle == nil {
+		t.Error("group route not found via Lookup")
+	}
+}
+
+func TestGroupHandler(t *testing.T) {
+	router := New()
+	g := router.Group("/files")
+	var name string
+	g.HandlerFunc(http.MethodGet, "/:name", func(w http.ResponseWriter, r *http.Request) {
+		name = ParamsFromContext(r.Context()).ByName("name")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files/a.txt", nil))
+	if name != "a.txt" {
+		t.Errorf("wrong param value: %q", name)
+	}
+}
+
+func TestGroupReplace(t *testing.T) {
+	var trace []string
+	router := New()
+	api := router.Group("/api", tagMiddleware("api", &trace))
+	api.GET("/ping", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		trace = append(trace, "old")
+	})
+	api.Replace(http.MethodGet, "/ping", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		trace = append(trace, "new")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	if got := strings.Join(trace, ","); got != "api,new" {
+		t.Errorf("replaced handle lost the middlewares: %s", got)
+	}
+}
+
+func TestGroupInvalidPrefix(t *testing.T) {
+	recv := catchPanic(func() {
+		New().Group("api")
+	})
+	if recv == nil {
+		t.Fatal("invalid group prefix did not panic")
+	}
+}
+
+func TestGroupConflictStillPanics(t *testing.T) {
+	router := New()
+	g := router.Group("/api")
+	g.GET("/users/:id", fakeHandler("/users/:id"))
+	recv := catchPanic(func() {
+		g.GET("/users/:name", fakeHandler("/users/:name"))
+	})
+	if recv == nil {
+		t.Fatal("conflicting wildcard did not panic")
+	}
+}
+
+func TestGroupStaticRouteNoAlloc(t *testing.T) {
+	router := New()
+	g := router.Group("/api", func(next Handle) Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			next(w, r, ps)
+		}
+	})
+	g.GET("/status", func(http.ResponseWriter, *http.Request, Params) {})
+
+	w := new(mockResponseWriter)
+	req, _ := http.NewRequest(http.MethodGet, "/api/status", nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(w, req)
+	})
+	if allocs != 0 {
+		t.Errorf("static group route should not allocate, got %v allocs", allocs)
+	}
+}
+
+func TestGroupHandleMethods(t *testing.T) {
+	var trace []string
+	router := New()
+	api := router.Group("/api", tagMiddleware("api", &trace))
+	api.HandleMethods([]string{http.MethodGet, http.MethodDelete}, "/items/:id?", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		trace = append(trace, r.Method)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/items", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/items/1", nil))
+	if got := strings.Join(trace, ","); got != "api,GET,api,DELETE" {
+		t.Errorf("wrong trace: %s", got)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrParamNotFound is returned by the typed accessors of Params if there is
+// no Param with the given name.
+var ErrParamNotFound = errors.New("httprouter: param not found")
+
+// lookup returns the value of the first Param which key matches the given
+// name.
+func (ps Params) lookup(name string) (string, error) {
+	for i := range ps {
+		if ps[i].Key == name {
+			return ps[i].Value, nil
+		}
+	}
+	return "", ErrParamNotFound
+}
+
+// Int returns the value of the Param with the given name as an int.
+// The error is ErrParamNotFound or a *strconv.NumError.
+//
+// Int 按名字获取参数并转换为 int，常与 ParamsFromContext 一起使用：
+// ParamsFromContext(r.Context()).Int("id")
+func (ps Params) Int(name string) (int, error) {
+	v, err := ps.lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(v)
+}
+
+// Int64 returns the value of the Param with the given name as an int64.
+func (ps Params) Int64(name string) (int64, error) {
+	v, err := ps.lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// Uint64 returns the value of the Param with the given name as an uint64.
+func (ps Params) Uint64(name string) (uint64, error) {
+	v, err := ps.lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// Bool returns the value of the Param with the given name as a bool, see
+// strconv.ParseBool for the accepted values.
+func (ps Params) Bool(name string) (bool, error) {
+	v, err := ps.lookup(name)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(v)
+}
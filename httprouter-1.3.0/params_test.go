@@ -0,0 +1,45 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParamsTyped(t *testing.T) {
+	ps := Params{
+		Param{"id", "42"},
+		Param{"neg", "-7"},
+		Param{"big", "18446744073709551615"},
+		Param{"flag", "true"},
+		Param{"name", "gopher"},
+	}
+
+	if v, err := ps.Int("id"); err != nil || v != 42 {
+		t.Errorf("Int(id) = %d, %v", v, err)
+	}
+	if v, err := ps.Int64("neg"); err != nil || v != -7 {
+		t.Errorf("Int64(neg) = %d, %v", v, err)
+	}
+	if v, err := ps.Uint64("big"); err != nil || v != 1<<64-1 {
+		t.Errorf("Uint64(big) = %d, %v", v, err)
+	}
+	if v, err := ps.Bool("flag"); err != nil || !v {
+		t.Errorf("Bool(flag) = %t, %v", v, err)
+	}
+
+	if _, err := ps.Int("missing"); err != ErrParamNotFound {
+		t.Errorf("expected ErrParamNotFound, got %v", err)
+	}
+	if _, err := ps.Int("name"); err == nil {
+		t.Error("no error for a non-numeric value")
+	} else if _, ok := err.(*strconv.NumError); !ok {
+		t.Errorf("expected a *strconv.NumError, got %T", err)
+	}
+	if _, err := Params(nil).Uint64("id"); err != ErrParamNotFound {
+		t.Errorf("expected ErrParamNotFound for nil Params, got %v", err)
+	}
+}
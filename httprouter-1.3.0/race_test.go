@@ -0,0 +1,10 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+//go:build race
+// +build race
+
+package httprouter
+
+const raceEnabled = true
@@ -84,6 +84,8 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Handle is a function that can be registered to a route to handle HTTP
@@ -139,7 +141,15 @@ func ParamsFromContext(ctx context.Context) Params {
 //
 // Router 路由引擎
 type Router struct {
-	trees map[string]*node
+	// Current *treeSet. It is loaded once per request, Remove and Replace
+	// publish a modified copy instead of changing it in place.
+	trees atomic.Value
+
+	// Serializes modifications of the trees
+	mu sync.Mutex
+
+	// Registered route names, mapping a name to the route path
+	names map[string]string
 
 	// Enables automatic redirection if the current route can't be matched but a
 	// handler for the path with (without) the trailing slash exists.
@@ -171,15 +181,27 @@ type Router struct {
 	// Custom OPTIONS handlers take priority over automatic replies.
 	HandleOPTIONS bool
 
+	// If enabled, HEAD requests for which no HEAD handle is registered are
+	// served by the GET handle of the path. The response body written by the
+	// handle is discarded.
+	// HEAD is then also listed in the "Allow" header of paths allowing GET.
+	// Redirects are applied like for GET requests. Disabled by default.
+	//
+	// 开启后 HEAD 请求会回退到 GET 的 handle，并丢弃响应体
+	HandleHEAD bool
+
+	// Optional CORS policy. If it is set, automatic OPTIONS replies to
+	// preflight requests carry the Access-Control-* headers for the path,
+	// and cross-origin requests to registered routes get the headers of the
+	// actual response. Create it with NewCORS to validate it.
+	CORS *CORS
+
 	// An optional http.Handler that is called on automatic OPTIONS requests.
 	// The handler is only called if HandleOPTIONS is true and no OPTIONS
 	// handler for the specific path was set.
 	// The "Allowed" header is set before calling the handler.
 	GlobalOPTIONS http.Handler
 
-	// Cached value of global (*) allowed methods
-	globalAllowed string
-
 	// Configurable http.Handler which is called when no matching route is
 	// found. If it is not set, http.NotFound is used.
 	NotFound http.Handler
@@ -191,12 +213,85 @@ type Router struct {
 	// is called.
 	MethodNotAllowed http.Handler
 
+	// If enabled, the default 404 and 405 responses are replaced by JSON
+	// problem details (RFC 7807) listing the allowed methods and the nearest
+	// registered routes, see Router.Suggest. Custom NotFound and
+	// MethodNotAllowed handlers keep priority.
+	// The responses expose the routing table, so this is meant for
+	// development only and disabled by default.
+	//
+	// 调试用：404/405 时返回包含相近路由建议的 JSON，生产环境不要开启
+	Diagnostics bool
+
 	// Function to handle panics recovered from http handlers.
 	// It should be used to generate a error page and return the http error code
 	// 500 (Internal Server Error).
 	// The handler can be used to keep your server from crashing because of
 	// unrecovered panics.
 	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
+
+	// If enabled, the Params are taken from a pool instead of being
+	// allocated for every request with path parameters.
+	// The Params passed to a handle are recycled after the handle returned,
+	// so they must not be retained (e.g. by goroutines) after that; copy
+	// them if needed. Params returned by Lookup can be given back with
+	// ReleaseParams.
+	//
+	// 开启后 Params 从 sync.Pool 中获取，handle 返回后回收，不能在 handle 之外继续持有
+	PoolParams bool
+
+	// Pool of *Params, see PoolParams
+	paramsPool sync.Pool
+
+	// If enabled, the Params of every matched route are stored in the
+	// request context under ParamsKey before its handle is called, not only
+	// for routes registered with Handler or HandlerFunc. Handles and
+	// http.Handler middleware stacks can then uniformly use
+	// ParamsFromContext and its typed accessors.
+	//
+	// 开启后所有 handle 都可以通过 ParamsFromContext 从请求上下文中获取参数
+	WithContextParams bool
+}
+
+// treeSet is a snapshot of the routing trees of a Router, one tree per
+// request method.
+//
+// treeSet 路由树的快照，发布之后只读，修改时复制一份新的(copy-on-write)
+type treeSet struct {
+	trees map[string]*node
+
+	// Cached value of global (*) allowed methods, without and with HEAD for
+	// GET (see Router.HandleHEAD)
+	globalAllowed     string
+	globalAllowedHEAD string
+}
+
+// copyTrees returns a shallow copy of the trees map, to be modified and
+// published with setTrees.
+func (ts *treeSet) copyTrees() map[string]*node {
+	trees := make(map[string]*node, len(ts.trees)+1)
+	for method, root := range ts.trees {
+		trees[method] = root
+	}
+	return trees
+}
+
+// getTrees returns the current snapshot of the routing trees.
+func (r *Router) getTrees() *treeSet {
+	ts, _ := r.trees.Load().(*treeSet)
+	if ts == nil {
+		return &treeSet{}
+	}
+	return ts
+}
+
+// setTrees publishes a new set of trees and refreshes the cached global
+// allowed methods.
+func (r *Router) setTrees(trees map[string]*node) {
+	ts := &treeSet{trees: trees}
+	ts.globalAllowed = ts.allowed("*", "", false)
+	ts.globalAllowedHEAD = ts.allowed("*", "", true)
+	r.trees.Store(ts)
 }
 
 // Make sure the Router conforms with the http.Handler interface
@@ -256,27 +351,211 @@ func (r *Router) DELETE(path string, handle Handle) {
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
+//
+// Trailing path segments can be marked as optional with a '?' suffix, e.g.
+// "/files/:name?" registers both "/files" and "/files/:name".
+//
+// Handle modifies the tree in place and must not be called concurrently with
+// ServeHTTP; use Replace to add or swap routes while serving requests.
 func (r *Router) Handle(method, path string, handle Handle) {
+	r.handle(method, path, handle, nil)
+}
+
+// handle registers a request handle together with its metadata.
+func (r *Router) handle(method, path string, handle Handle, meta Meta) {
 	// 如果 path 为空字符串或者不以 '/' 开头
 	if len(path) < 1 || path[0] != '/' {
 		panic("path must begin with '/' in path '" + path + "'")
 	}
-	// 初始化前缀树map
-	if r.trees == nil {
-		r.trees = make(map[string]*node)
-	}
+	paths := expandOptional(path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addRoutes(method, paths, handle, meta)
+}
+
+// addRoutes inserts the expanded paths into the tree of the method and
+// returns the nodes holding the handle. r.mu must be held.
+func (r *Router) addRoutes(method string, paths []string, handle Handle, meta Meta) (leaves []*node) {
 	// 获得对应模式的树
-	root := r.trees[method]
+	ts := r.getTrees()
+	root := ts.trees[method]
 	// 初始化树
 	if root == nil {
 		root = new(node)
-		r.trees[method] = root
+		trees := ts.copyTrees()
+		trees[method] = root
 		// 全局添加路由允许的请求方法
 		// 当 Handle 接收了一种新的请求方法时，创建该方法的根节点并将其添加进全局 globalAllowed 缓存中。
-		r.globalAllowed = r.allowed("*", "")
+		r.setTrees(trees)
 	}
 	// 注册路由
-	root.addRoute(path, handle)
+	for _, path := range paths {
+		leaf := root.addRoute(path, handle)
+		leaf.meta = meta
+		leaves = append(leaves, leaf)
+	}
+	return leaves
+}
+
+// HandleMethods registers the request handle for the given path and each of
+// the given methods, like calling Handle for every method.
+//
+// HandleMethods 为同一路径一次注册多个请求方法
+func (r *Router) HandleMethods(methods []string, path string, handle Handle) {
+	if len(methods) == 0 {
+		panic("no methods given for path '" + path + "'")
+	}
+	for _, method := range methods {
+		r.Handle(method, path, handle)
+	}
+}
+
+// expandOptional expands the optional trailing segments of a path, which are
+// params with a '?' suffix like ":name?" or "{id:int}?", into all paths they
+// stand for, shortest first. A path without optional segments is returned as
+// it is.
+//
+// expandOptional 将末尾的可选参数段展开为多条路由，例如
+// "/files/:name?" 展开为 "/files" 和 "/files/:name"
+func expandOptional(path string) []string {
+	if strings.IndexByte(path, '?') < 0 {
+		return []string{path}
+	}
+
+	var paths []string
+	var current string // last expanded path
+	start := 0         // start of the current segment, after its '/'
+	for start <= len(path) {
+		end := start + strings.IndexByte(path[start:], '/')
+		if end < start {
+			end = len(path)
+		}
+		seg := path[start:end]
+
+		optional := len(seg) > 2 && seg[len(seg)-1] == '?' &&
+			(seg[0] == ':' || (seg[0] == '{' && seg[len(seg)-2] == '}'))
+		if optional {
+			if paths == nil {
+				// path up to the first optional segment
+				current = path[:start-1]
+				if current == "" {
+					paths = append(paths, "/")
+				} else {
+					paths = append(paths, current)
+				}
+			}
+			current += "/" + seg[:len(seg)-1]
+			paths = append(paths, current)
+		} else if paths != nil {
+			panic("optional segments are only allowed at the end of the path in path '" + path + "'")
+		}
+		start = end + 1
+	}
+	if paths == nil {
+		return []string{path}
+	}
+	return paths
+}
+
+// Remove removes the request handle registered with the given method and
+// path. The path must be given exactly as it was registered, e.g.
+// "/user/:name". A path with optional segments removes all the routes it
+// registered. It reports whether such a route existed.
+//
+// Remove is safe for concurrent use with ServeHTTP: the tree of the method
+// is rebuilt without the route and swapped in atomically, requests which are
+// already being served keep using the old tree.
+//
+// Remove 删除路由：重新构建该请求方法的前缀树后原子替换
+func (r *Router) Remove(method, path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts := r.getTrees()
+	root := ts.trees[method]
+	if root == nil {
+		return false
+	}
+	var paths []string
+	for _, path := range expandOptional(path) {
+		if root.find(path) != nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return false
+	}
+
+	trees := ts.copyTrees()
+	if newRoot := root.without(paths...); newRoot != nil {
+		trees[method] = newRoot
+	} else {
+		delete(trees, method)
+	}
+	r.setTrees(trees)
+	r.pruneNames()
+	return true
+}
+
+// pruneNames drops the route names which are no longer registered for any
+// method, so that URL does not build paths of removed routes.
+// r.mu must be held.
+func (r *Router) pruneNames() {
+	registered := make(map[string]bool, len(r.names))
+	for _, root := range r.getTrees().trees {
+		root.walk(func(leaf *node) {
+			if leaf.name != "" {
+				registered[leaf.name] = true
+			}
+		})
+	}
+	for name := range r.names {
+		if !registered[name] {
+			delete(r.names, name)
+		}
+	}
+}
+
+// Replace registers the request handle for the given method and path, or
+// swaps the handle if the route already exists. The metadata of an existing
+// route is kept.
+//
+// Unlike Handle, Replace is safe for concurrent use with ServeHTTP: it
+// modifies a copy of the tree and swaps it in atomically, so every request
+// is served either by the old or by the new handle.
+//
+// Replace 替换(或新增)路由：修改前缀树的副本后原子替换
+func (r *Router) Replace(method, path string, handle Handle) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	if handle == nil {
+		panic("handle must not be nil in path '" + path + "'")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts := r.getTrees()
+	var root *node
+	if old := ts.trees[method]; old != nil {
+		root = old.clone()
+	} else {
+		root = new(node)
+	}
+
+	for _, path := range expandOptional(path) {
+		if leaf := root.find(path); leaf != nil {
+			leaf.handle = handle
+		} else {
+			// panics on conflicts, before anything was published
+			root.addRoute(path, handle)
+		}
+	}
+
+	trees := ts.copyTrees()
+	trees[method] = root
+	r.setTrees(trees)
 }
 
 // Handler is an adapter which allows the usage of an http.Handler as a
@@ -285,16 +564,30 @@ func (r *Router) Handle(method, path string, handle Handle) {
 //
 // Handler 是用来兼容 http.Handler 的
 func (r *Router) Handler(method, path string, handler http.Handler) {
-	r.Handle(method, path,
-		func(w http.ResponseWriter, req *http.Request, p Params) {
-			if len(p) > 0 {
-				ctx := req.Context()
-				ctx = context.WithValue(ctx, ParamsKey, p)
-				req = req.WithContext(ctx)
-			}
-			handler.ServeHTTP(w, req)
-		},
-	)
+	r.Handle(method, path, handlerToHandle(handler))
+}
+
+// handlerToHandle wraps an http.Handler into a Handle which stores the Params
+// in the request context.
+func handlerToHandle(handler http.Handler) Handle {
+	return func(w http.ResponseWriter, req *http.Request, p Params) {
+		// the router already stored them with WithContextParams
+		if len(p) > 0 && !sameParams(ParamsFromContext(req.Context()), p) {
+			req = requestWithParams(req, p)
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// requestWithParams returns a shallow copy of req with the Params stored in
+// its context.
+func requestWithParams(req *http.Request, ps Params) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), ParamsKey, ps))
+}
+
+// sameParams reports whether a and b are the same non-empty Params slice.
+func sameParams(a, b Params) bool {
+	return len(a) > 0 && len(a) == len(b) && &a[0] == &b[0]
 }
 
 // HandlerFunc is an adapter which allows the usage of an http.HandlerFunc as a
@@ -344,15 +637,65 @@ func (r *Router) recv(w http.ResponseWriter, req *http.Request) {
 // values. Otherwise the third return value indicates whether a redirection to
 // the same path with an extra / without the trailing slash should be performed.
 func (r *Router) Lookup(method, path string) (Handle, Params, bool) {
-	if root := r.trees[method]; root != nil {
-		return root.getValue(path)
+	if root := r.getTrees().trees[method]; root != nil {
+		handle, ps, _, tsr := r.getValue(root, path)
+		return handle, ps, tsr
 	}
 	return nil, nil, false
 }
 
+// ReleaseParams gives Params returned by Lookup back to the pool, if
+// PoolParams is enabled. The Params must not be used afterwards.
+//
+// ReleaseParams 将 Lookup 返回的 Params 放回池中
+func (r *Router) ReleaseParams(ps Params) {
+	if r.PoolParams && cap(ps) > 0 {
+		ps = ps[:0]
+		r.paramsPool.Put(&ps)
+	}
+}
+
+// getValue looks up the path in the given tree. If PoolParams is enabled and
+// the route has path parameters, the Params are taken from the pool and psp
+// must be passed to putParams once they are no longer used.
+func (r *Router) getValue(root *node, path string) (handle Handle, ps Params, psp *Params, tsr bool) {
+	if !r.PoolParams || root.maxParams == 0 {
+		handle, ps, tsr = root.getValue(path)
+		return
+	}
+
+	psp, _ = r.paramsPool.Get().(*Params)
+	if psp == nil || cap(*psp) < int(root.maxParams) {
+		ps := make(Params, 0, root.maxParams)
+		psp = &ps
+	}
+	leaf, ps, tsr := root.getLeaf(path, *psp)
+	if leaf == nil || len(ps) == 0 {
+		// nothing to recycle later on
+		r.paramsPool.Put(psp)
+		if leaf == nil {
+			return nil, nil, nil, tsr
+		}
+		return leaf.handle, nil, nil, tsr
+	}
+	return leaf.handle, ps, psp, tsr
+}
+
+// putParams gives pooled Params returned by getValue back to the pool.
+func (r *Router) putParams(psp *Params) {
+	if psp != nil {
+		r.paramsPool.Put(psp)
+	}
+}
+
 // allowed 针对给定 path ，如果给定 reqMethod ，则查找其他允许的 method。
 // 是实现 Router.HandleMethodNotAllowed 机制的一部分
 func (r *Router) allowed(path, reqMethod string) (allow string) {
+	return r.getTrees().allowed(path, reqMethod, r.HandleHEAD)
+}
+
+// autoHEAD tells whether HEAD is allowed wherever GET is allowed.
+func (ts *treeSet) allowed(path, reqMethod string, autoHEAD bool) (allow string) {
 	// 设置容量 9 是因为除了 http.MethodOptions 共 9 种方法
 	allowed := make([]string, 0, 9)
 
@@ -360,7 +703,7 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 		// empty method is used for internal calls to refresh the cache
 		// 如果 reqMethod 为空则用于内部调用以刷新缓存，就是刷新 r.globalAllowed
 		if reqMethod == "" {
-			for method := range r.trees {
+			for method := range ts.trees {
 				if method == http.MethodOptions {
 					continue
 				}
@@ -370,17 +713,20 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 			}
 		} else {
 			// 如果 reqMethod 为空则直接返回 r.globalAllowed
-			return r.globalAllowed
+			if autoHEAD {
+				return ts.globalAllowedHEAD
+			}
+			return ts.globalAllowed
 		}
 	} else { // specific path 指定路由
-		for method := range r.trees {
+		for method := range ts.trees {
 			// Skip the requested method - we already tried this one
 			// 跳过请求的方法 - 毫无疑问请求的方法是被允许的
 			if method == reqMethod || method == http.MethodOptions {
 				continue
 			}
 			// 看看其他 method 能不能找到对应的 handle
-			handle, _, _ := r.trees[method].getValue(path)
+			handle, _, _ := ts.trees[method].getValue(path)
 			if handle != nil {
 				// Add request method to list of allowed methods
 				allowed = append(allowed, method)
@@ -389,6 +735,10 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 	}
 
 	if len(allowed) > 0 {
+		if autoHEAD {
+			allowed = addHEAD(allowed)
+		}
+
 		// Add request method to list of allowed methods
 		allowed = append(allowed, http.MethodOptions)
 
@@ -408,6 +758,63 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 	return
 }
 
+// addHEAD adds HEAD to the allowed methods if they contain GET but not HEAD.
+func addHEAD(allowed []string) []string {
+	hasGET := false
+	for _, method := range allowed {
+		switch method {
+		case http.MethodHead:
+			return allowed
+		case http.MethodGet:
+			hasGET = true
+		}
+	}
+	if hasGET {
+		allowed = append(allowed, http.MethodHead)
+	}
+	return allowed
+}
+
+// redirect redirects a request whose path has no route in root to the path
+// with (without) the trailing slash or to the case-insensitive match of the
+// path, if the respective option is enabled. It reports whether it did.
+func (r *Router) redirect(w http.ResponseWriter, req *http.Request, root *node, path string, tsr bool) bool {
+	if req.Method == http.MethodConnect || path == "/" {
+		return false
+	}
+	// 这里是没找到后采取的措施，即进行重定向
+	code := 301 // Permanent redirect, request with GET method
+	if req.Method != http.MethodGet {
+		// Temporary redirect, request with same method
+		// As of Go 1.3, Go does not support status code 308.
+		code = 307
+	}
+
+	if tsr && r.RedirectTrailingSlash {
+		if len(path) > 1 && path[len(path)-1] == '/' {
+			req.URL.Path = path[:len(path)-1]
+		} else {
+			req.URL.Path = path + "/"
+		}
+		http.Redirect(w, req, req.URL.String(), code)
+		return true
+	}
+
+	// Try to fix the request path
+	if r.RedirectFixedPath {
+		fixedPath, found := root.findCaseInsensitivePath(
+			CleanPath(path),
+			r.RedirectTrailingSlash,
+		)
+		if found {
+			req.URL.Path = string(fixedPath)
+			http.Redirect(w, req, req.URL.String(), code)
+			return true
+		}
+	}
+	return false
+}
+
 // ServeHTTP makes the router implement the http.Handler interface.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if r.PanicHandler != nil {
@@ -415,60 +822,63 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 	// 获得当前请求的 URL path
 	path := req.URL.Path
+	ts := r.getTrees()
 	// 匹配对应 method
-	if root := r.trees[req.Method]; root != nil {
-		if handle, ps, tsr := root.getValue(path); handle != nil {
+	if root := ts.trees[req.Method]; root != nil {
+		if handle, ps, psp, tsr := r.getValue(root, path); handle != nil {
+			if r.CORS != nil {
+				r.CORS.setHeaders(w, req)
+			}
+			if r.WithContextParams && len(ps) > 0 {
+				req = requestWithParams(req, ps)
+			}
 			// 找到了就直接调用并返回
 			handle(w, req, ps)
+			r.putParams(psp)
 			return
-		} else if req.Method != http.MethodConnect && path != "/" {
-			// 这里是没找到后采取的措施，即进行重定向
-			code := 301 // Permanent redirect, request with GET method
-			if req.Method != http.MethodGet {
-				// Temporary redirect, request with same method
-				// As of Go 1.3, Go does not support status code 308.
-				code = 307
-			}
+		} else if r.redirect(w, req, root, path, tsr) {
+			return
+		}
+	}
 
-			if tsr && r.RedirectTrailingSlash {
-				if len(path) > 1 && path[len(path)-1] == '/' {
-					req.URL.Path = path[:len(path)-1]
-				} else {
-					req.URL.Path = path + "/"
+	// Serve HEAD requests with the GET handle, or redirect like GET
+	if req.Method == http.MethodHead && r.HandleHEAD {
+		if root := ts.trees[http.MethodGet]; root != nil {
+			if handle, ps, psp, tsr := r.getValue(root, path); handle != nil {
+				if r.CORS != nil {
+					r.CORS.setHeaders(w, req)
 				}
-				http.Redirect(w, req, req.URL.String(), code)
-				return
-			}
-
-			// Try to fix the request path
-			if r.RedirectFixedPath {
-				fixedPath, found := root.findCaseInsensitivePath(
-					CleanPath(path),
-					r.RedirectTrailingSlash,
-				)
-				if found {
-					req.URL.Path = string(fixedPath)
-					http.Redirect(w, req, req.URL.String(), code)
-					return
+				if r.WithContextParams && len(ps) > 0 {
+					req = requestWithParams(req, ps)
 				}
+				handle(headResponseWriter{w}, req, ps)
+				r.putParams(psp)
+				return
+			} else if r.redirect(w, req, root, path, tsr) {
+				return
 			}
 		}
 	}
 
 	if req.Method == http.MethodOptions && r.HandleOPTIONS {
 		// Handle OPTIONS requests
-		if allow := r.allowed(path, http.MethodOptions); allow != "" {
+		if allow := ts.allowed(path, http.MethodOptions, r.HandleHEAD); allow != "" {
 			w.Header().Set("Allow", allow)
+			if r.CORS != nil {
+				r.CORS.setPreflightHeaders(w, req, allow)
+			}
 			if r.GlobalOPTIONS != nil {
 				r.GlobalOPTIONS.ServeHTTP(w, req)
 			}
 			return
 		}
 	} else if r.HandleMethodNotAllowed { // Handle 405
-		if allow := r.allowed(path, req.Method); allow != "" {
+		if allow := ts.allowed(path, req.Method, r.HandleHEAD); allow != "" {
 			w.Header().Set("Allow", allow)
 			if r.MethodNotAllowed != nil {
 				r.MethodNotAllowed.ServeHTTP(w, req)
+			} else if r.Diagnostics {
+				r.writeProblem(w, req, http.StatusMethodNotAllowed, allow)
 			} else {
 				http.Error(w,
 					http.StatusText(http.StatusMethodNotAllowed),
@@ -482,7 +892,24 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// Handle 404
 	if r.NotFound != nil {
 		r.NotFound.ServeHTTP(w, req)
+	} else if r.Diagnostics {
+		r.writeProblem(w, req, http.StatusNotFound, ts.allowed(path, req.Method, r.HandleHEAD))
 	} else {
 		http.NotFound(w, req)
 	}
 }
+
+// headResponseWriter discards the body written by a GET handle which serves
+// a HEAD request.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap returns the original http.ResponseWriter, for http.ResponseController.
+func (w headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
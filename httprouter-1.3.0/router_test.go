@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -237,6 +238,62 @@ func BenchmarkAllowed(b *testing.B) {
 	})
 }
 
+func TestRouterHEADFallback(t *testing.T) {
+	router := New()
+	router.HandleHEAD = true
+	router.GET("/user/:name", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Header().Set("X-User", ps.ByName("name"))
+		w.Write([]byte("hello " + ps.ByName("name")))
+	})
+	router.GET("/explicit", fakeHandler("get"))
+	router.HEAD("/explicit", fakeHandler("head"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/user/gopher", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("HEAD fallback failed: Code=%d", w.Code)
+	}
+	if w.Header().Get("X-User") != "gopher" {
+		t.Error("headers of the GET handle are missing")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body was not discarded: %q", w.Body.String())
+	}
+
+	// a registered HEAD handle takes priority
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/explicit", nil))
+	if fakeHandlerValue != "head" {
+		t.Errorf("wrong handle for HEAD: %s", fakeHandlerValue)
+	}
+
+	// 405 responses list HEAD for GET routes
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user/gopher", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected 405 with Allow 'GET, HEAD, OPTIONS', got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// redirects like GET
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/user/gopher/", nil))
+	if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != "/user/gopher" {
+		t.Errorf("expected 307 to /user/gopher, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/USER/gopher", nil))
+	if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != "/user/gopher" {
+		t.Errorf("expected 307 to the fixed path, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	// disabled
+	router.HandleHEAD = false
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/user/gopher", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("expected 405 with Allow 'GET, OPTIONS', got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
 func TestRouterOPTIONS(t *testing.T) {
 	handlerFunc := func(_ http.ResponseWriter, _ *http.Request, _ Params) {}
 
@@ -527,6 +584,333 @@ func TestRouterLookup(t *testing.T) {
 	}
 }
 
+func TestRouterRemove(t *testing.T) {
+	router := New()
+	router.GET("/user/:name", fakeHandler("get"))
+	router.PUT("/user/:name", fakeHandler("put"))
+	router.GET("/users", fakeHandler("list"))
+
+	if router.Remove(http.MethodGet, "/user/:other") {
+		t.Error("removing an unknown route reported success")
+	}
+	if !router.Remove(http.MethodGet, "/user/:name") {
+		t.Fatal("removing a registered route failed")
+	}
+
+	if handle, _, _ := router.Lookup(http.MethodGet, "/user/gopher"); handle != nil {
+		t.Error("removed route is still routed")
+	}
+	if handle, _, _ := router.Lookup(http.MethodGet, "/users"); handle == nil {
+		t.Error("remaining route got lost")
+	}
+
+	// the path is still served for PUT, so GET is now 405
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/gopher", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "OPTIONS, PUT" {
+		t.Errorf("expected 405 with Allow 'OPTIONS, PUT', got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// removing the last route of a method drops its tree
+	router.Remove(http.MethodPut, "/user/:name")
+	if got := router.allowed("*", ""); got != "GET, OPTIONS" {
+		t.Errorf("wrong global allowed methods: %q", got)
+	}
+
+	// the route can be registered again
+	router.GET("/user/:name", fakeHandler("again"))
+	if handle, _, _ := router.Lookup(http.MethodGet, "/user/gopher"); handle == nil {
+		t.Error("route could not be registered again after removal")
+	}
+}
+
+func TestRouterReplace(t *testing.T) {
+	router := New()
+	router.GET("/user/:name", fakeHandler("old"))
+
+	router.Replace(http.MethodGet, "/user/:name", fakeHandler("new"))
+	router.Replace(http.MethodPost, "/user/:name", fakeHandler("post"))
+
+	for method, want := range map[string]string{http.MethodGet: "new", http.MethodPost: "post"} {
+		handle, ps, _ := router.Lookup(method, "/user/gopher")
+		if handle == nil {
+			t.Fatalf("no handle for %s", method)
+		}
+		handle(nil, nil, ps)
+		if fakeHandlerValue != want {
+			t.Errorf("wrong handle for %s: %s, want %s", method, fakeHandlerValue, want)
+		}
+	}
+
+	// conflicts panic without changing the routing table
+	recv := catchPanic(func() {
+		router.Replace(http.MethodGet, "/user/:id/posts", fakeHandler("conflict"))
+	})
+	if recv == nil {
+		t.Fatal("conflicting route did not panic")
+	}
+	if handle, _, _ := router.Lookup(http.MethodGet, "/user/gopher"); handle == nil {
+		t.Error("routing table was changed by a failed Replace")
+	}
+}
+
+func TestRouterReplaceConcurrent(t *testing.T) {
+	router := New()
+	handles := [2]Handle{
+		func(w http.ResponseWriter, _ *http.Request, _ Params) { w.WriteHeader(http.StatusOK) },
+		func(w http.ResponseWriter, _ *http.Request, _ Params) { w.WriteHeader(http.StatusAccepted) },
+	}
+	router.GET("/flag/:name", handles[0])
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flag/beta", nil))
+				if w.Code != http.StatusOK && w.Code != http.StatusAccepted {
+					t.Errorf("unexpected status %d while swapping routes", w.Code)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		router.Replace(http.MethodGet, "/flag/:name", handles[i%2])
+		router.Replace(http.MethodGet, "/other", handles[0])
+		router.Remove(http.MethodGet, "/other")
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestRouterPoolParams(t *testing.T) {
+	router := New()
+	router.PoolParams = true
+
+	var got []string
+	router.GET("/user/:name/:tab", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		got = append(got, ps.ByName("name")+"/"+ps.ByName("tab"))
+	})
+	router.GET("/static", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if ps != nil {
+			t.Errorf("static route got params: %v", ps)
+		}
+	})
+
+	w := new(mockResponseWriter)
+	for _, path := range []string{"/user/gopher/repos", "/static", "/user/julien/stars"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+	}
+	if want := []string{"gopher/repos", "julien/stars"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong params: %v, want %v", got, want)
+	}
+
+	// Lookup with ReleaseParams
+	handle, ps, _ := router.Lookup(http.MethodGet, "/user/gopher/repos")
+	if handle == nil || ps.ByName("tab") != "repos" {
+		t.Fatalf("Lookup failed: %v", ps)
+	}
+	router.ReleaseParams(ps)
+	if _, ps, _ = router.Lookup(http.MethodGet, "/user/a/b"); ps.ByName("name") != "a" || ps.ByName("tab") != "b" {
+		t.Errorf("wrong params after ReleaseParams: %v", ps)
+	}
+}
+
+func TestRouterPoolParamsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector drops pooled items")
+	}
+
+	allocs := func(pool bool) float64 {
+		router := New()
+		router.PoolParams = pool
+		router.GET("/user/:name", func(http.ResponseWriter, *http.Request, Params) {})
+		w := new(mockResponseWriter)
+		req, _ := http.NewRequest(http.MethodGet, "/user/gopher", nil)
+		return testing.AllocsPerRun(100, func() {
+			router.ServeHTTP(w, req)
+		})
+	}
+
+	if pooled := allocs(true); pooled != 0 {
+		t.Errorf("pooled Params should not allocate, got %v allocs", pooled)
+	}
+	if plain := allocs(false); plain != 1 {
+		t.Errorf("expected 1 alloc without pool, got %v allocs", plain)
+	}
+}
+
+func TestRouterWithContextParams(t *testing.T) {
+	router := New()
+	router.WithContextParams = true
+
+	var fromHandle, fromHandler int
+	router.GET("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		fromHandle, _ = ParamsFromContext(r.Context()).Int("id")
+	})
+
+	// a chain of standard middlewares, like alice.New(m1, m2).Then(h)
+	var trace []string
+	chain := func(h http.Handler, tags ...string) http.Handler {
+		for i := len(tags) - 1; i >= 0; i-- {
+			tag, next := tags[i], h
+			h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace = append(trace, tag)
+				next.ServeHTTP(w, r)
+			})
+		}
+		return h
+	}
+	router.Handler(http.MethodGet, "/posts/:id", chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromHandler, _ = ParamsFromContext(r.Context()).Int("id")
+	}), "m1", "m2"))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/7", nil))
+
+	if fromHandle != 42 {
+		t.Errorf("Handle got id %d from context, want 42", fromHandle)
+	}
+	if fromHandler != 7 {
+		t.Errorf("Handler got id %d from context, want 7", fromHandler)
+	}
+	if !reflect.DeepEqual(trace, []string{"m1", "m2"}) {
+		t.Errorf("wrong middleware order: %v", trace)
+	}
+
+	// disabled: only Handler routes get the context params
+	router.WithContextParams = false
+	fromHandle = -1
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if fromHandle != 0 {
+		t.Errorf("Handle got id %d from context without WithContextParams", fromHandle)
+	}
+}
+
+func TestSameParams(t *testing.T) {
+	ps := Params{Param{"id", "1"}}
+	if !sameParams(ps, ps) {
+		t.Error("identical Params not detected")
+	}
+	if sameParams(ps, Params{Param{"id", "1"}}) {
+		t.Error("equal but distinct Params must not be the same")
+	}
+	if sameParams(nil, nil) {
+		t.Error("empty Params must not be the same")
+	}
+}
+
+func TestExpandOptional(t *testing.T) {
+	tests := []struct {
+		path  string
+		paths []string
+	}{
+		{"/files", []string{"/files"}},
+		{"/files/:name?", []string{"/files", "/files/:name"}},
+		{"/:name?", []string{"/", "/:name"}},
+		{"/img/:dir?/:name?", []string{"/img", "/img/:dir", "/img/:dir/:name"}},
+		{"/users/{id:int}?", []string{"/users", "/users/{id:int}"}},
+		{"/re/{x:ab?}", []string{"/re/{x:ab?}"}},
+		{"/re/{x:ab?}?", []string{"/re", "/re/{x:ab?}"}},
+		{"/user_:name?", []string{"/user_:name?"}},
+	}
+	for _, test := range tests {
+		if paths := expandOptional(test.path); !reflect.DeepEqual(paths, test.paths) {
+			t.Errorf("expandOptional(%q) = %q, want %q", test.path, paths, test.paths)
+		}
+	}
+
+	for _, path := range []string{"/files/:name?/raw", "/files/:name?/", "/a/:b?/c/:d?"} {
+		if recv := catchPanic(func() { expandOptional(path) }); recv == nil {
+			t.Errorf("no panic for non-trailing optional segment in %q", path)
+		}
+	}
+}
+
+func TestRouterOptionalSegments(t *testing.T) {
+	router := New()
+	var got string
+	router.GET("/files/:dir?/:name?", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		got = ps.ByName("dir") + "|" + ps.ByName("name")
+	})
+
+	for path, want := range map[string]string{
+		"/files":     "|",
+		"/files/a":   "a|",
+		"/files/a/b": "a|b",
+	} {
+		got = "none"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got != want {
+			t.Errorf("GET %s: got %q, want %q", path, got, want)
+		}
+	}
+
+	// all expanded routes are removed together
+	if !router.Remove(http.MethodGet, "/files/:dir?/:name?") {
+		t.Fatal("removing the optional route failed")
+	}
+	if len(router.Routes()) != 0 {
+		t.Errorf("routes left after removal: %v", router.Routes())
+	}
+
+	// conflicts of any expansion still panic
+	router.GET("/users/:id", fakeHandler("/users/:id"))
+	for _, path := range []string{"/users/:name?", "/users/:id?"} {
+		if recv := catchPanic(func() { router.GET(path, fakeHandler(path)) }); recv == nil {
+			t.Errorf("no panic for conflicting optional route %q", path)
+		}
+	}
+	if recv := catchPanic(func() { router.GET("/posts/:id?/comments", fakeHandler("x")) }); recv == nil {
+		t.Error("no panic for non-trailing optional segment")
+	}
+}
+
+func TestRouterHandleMethods(t *testing.T) {
+	router := New()
+	var method string
+	router.HandleMethods([]string{http.MethodGet, http.MethodPost, "PURGE"}, "/cache/:key?", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		method = r.Method
+	})
+
+	for _, m := range []string{http.MethodGet, http.MethodPost, "PURGE"} {
+		for _, path := range []string{"/cache", "/cache/a"} {
+			method = ""
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(m, path, nil))
+			if method != m {
+				t.Errorf("%s %s was not routed", m, path)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/cache/a", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, OPTIONS, POST, PURGE" {
+		t.Errorf("expected 405 with all registered methods, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	if recv := catchPanic(func() {
+		router.HandleMethods([]string{http.MethodPut, http.MethodGet}, "/cache/:key", fakeHandler("dup"))
+	}); recv == nil {
+		t.Error("duplicate registration did not panic")
+	}
+	if recv := catchPanic(func() { router.HandleMethods(nil, "/x", fakeHandler("x")) }); recv == nil {
+		t.Error("empty methods did not panic")
+	}
+}
+
 func TestRouterParamsFromContext(t *testing.T) {
 	routed := false
 
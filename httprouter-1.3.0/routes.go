@@ -0,0 +1,234 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Route describes a registered route, as returned by Router.Routes.
+type Route struct {
+	Method string
+	Path   string
+	Name   string // empty if the route was registered without a name
+	Handle Handle
+	Meta   Meta // nil if the route was registered without metadata
+}
+
+// Meta is arbitrary metadata attached to a route, e.g. an authorization scope
+// or documentation, for tooling built around the router.
+// It is stored as given and must not be modified after the registration.
+//
+// Meta 路由元数据，与 handle 一起存储在前缀树的节点上
+type Meta map[string]interface{}
+
+// HandleMeta registers a new request handle like Handle and attaches the
+// given metadata to the route. It can be retrieved with LookupRoute, Routes
+// and Walk.
+func (r *Router) HandleMeta(method, path string, handle Handle, meta Meta) {
+	r.handle(method, path, handle, meta)
+}
+
+// HandleMeta registers a request handle with metadata relative to the
+// Group's prefix, wrapped with the Group's middlewares.
+func (g *Group) HandleMeta(method, path string, handle Handle, meta Meta) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	g.router.HandleMeta(method, g.prefix+path, g.wrap(handle), meta)
+}
+
+// LookupRoute is like Lookup, but returns the whole matched route including
+// its registered path, name and metadata.
+// If no route matches, the route is nil and the third return value indicates
+// whether a redirection to the same path with an extra / without the trailing
+// slash should be performed.
+//
+// LookupRoute 查找路由，返回包括元数据在内的完整路由信息
+func (r *Router) LookupRoute(method, path string) (*Route, Params, bool) {
+	root := r.getTrees().trees[method]
+	if root == nil {
+		return nil, nil, false
+	}
+	leaf, ps, tsr := root.getLeaf(path, nil)
+	if leaf == nil {
+		return nil, ps, tsr
+	}
+	route := &Route{
+		Method: method,
+		Path:   leaf.fullPath,
+		Name:   leaf.name,
+		Handle: leaf.handle,
+		Meta:   leaf.meta,
+	}
+	return route, ps, false
+}
+
+// HandleNamed registers a new request handle like Handle and gives the route
+// a name, which can be used to build URLs with Router.URL.
+// The name belongs to the given method only; the same name may be used for
+// several methods of the same path, but not for different paths.
+//
+// HandleNamed 注册路由的同时为其命名，用于反向生成 URL
+func (r *Router) HandleNamed(name, method, path string, handle Handle) {
+	if name == "" {
+		panic("route name must not be empty for path '" + path + "'")
+	}
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	paths := expandOptional(path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.names[name]; ok && existing != path {
+		panic("route name '" + name + "' is already registered for path '" + existing + "'")
+	}
+	for _, leaf := range r.addRoutes(method, paths, handle, nil) {
+		leaf.name = name
+	}
+	if r.names == nil {
+		r.names = make(map[string]string)
+	}
+	r.names[name] = path
+}
+
+// HandleNamed registers a named request handle relative to the Group's
+// prefix, wrapped with the Group's middlewares.
+func (g *Group) HandleNamed(name, method, path string, handle Handle) {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	g.router.HandleNamed(name, method, g.prefix+path, g.wrap(handle))
+}
+
+// URL builds the path of the route with the given name.
+// The params are key-value pairs, e.g. URL("user", "name", "gopher") for a
+// route "/user/:name". Values of constrained parameters must match their
+// pattern. Values of named parameters are path-escaped, values of
+// catch-all parameters are escaped segment by segment and may start with '/'.
+// Optional segments without a value are left out.
+// An error is returned if the name is unknown or a parameter is missing.
+//
+// URL 根据路由名称和参数反向生成路径
+func (r *Router) URL(name string, params ...string) (string, error) {
+	r.mu.Lock()
+	path, ok := r.names[name]
+	r.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("httprouter: no route named '%s'", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("httprouter: odd number of params for route '%s'", name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	buf := make([]byte, 0, len(path)+len(params)*8)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		constraint := isConstraint(path, i)
+		if c != ':' && c != '*' && !constraint {
+			buf = append(buf, c)
+			continue
+		}
+
+		// find wildcard end (either '/' or path end)
+		end := i + 1
+		for end < len(path) && path[end] != '/' {
+			end++
+		}
+		seg := path[i:end]
+		optional := c != '*' && strings.HasSuffix(seg, "?")
+		if optional {
+			seg = seg[:len(seg)-1]
+		}
+		key := seg[1:]
+		var re *regexp.Regexp
+		if constraint {
+			// the route has been registered, so the segment is valid
+			key, re, _ = parseConstraint(seg)
+		}
+		value, ok := values[key]
+		if !ok {
+			if optional {
+				// leave out the remaining optional segments, including
+				// the '/' in front of this one
+				buf = buf[:len(buf)-1]
+				if len(buf) == 0 {
+					buf = append(buf, '/')
+				}
+				break
+			}
+			return "", fmt.Errorf("httprouter: missing param '%s' for route '%s'", key, name)
+		}
+		if re != nil && !re.MatchString(value) {
+			return "", fmt.Errorf("httprouter: param '%s' does not match the constraint '%s' of route '%s'", key, seg, name)
+		}
+
+		if c != '*' {
+			if value == "" {
+				return "", fmt.Errorf("httprouter: empty param '%s' for route '%s'", key, name)
+			}
+			buf = append(buf, url.PathEscape(value)...)
+		} else {
+			// catch-all values include the leading '/', like the Params
+			// returned by the router do
+			value = strings.TrimPrefix(value, "/")
+			segments := strings.Split(value, "/")
+			for j := range segments {
+				segments[j] = url.PathEscape(segments[j])
+			}
+			buf = append(buf, strings.Join(segments, "/")...)
+		}
+		i = end - 1
+	}
+	return string(buf), nil
+}
+
+// Routes returns all registered routes, sorted by path and method.
+// It walks the trees, so the result always reflects the current routing table.
+//
+// Routes 遍历前缀树，返回所有已注册的路由
+func (r *Router) Routes() []Route {
+	var routes []Route
+	for method, root := range r.getTrees().trees {
+		root.walk(func(n *node) {
+			routes = append(routes, Route{
+				Method: method,
+				Path:   n.fullPath,
+				Name:   n.name,
+				Handle: n.handle,
+				Meta:   n.meta,
+			})
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Walk calls fn for every registered route, in the order of Routes.
+// If fn returns an error, the walk stops and the error is returned.
+//
+// Walk 遍历所有路由，可用于根据元数据生成文档或鉴权配置
+func (r *Router) Walk(fn func(route Route) error) error {
+	for _, route := range r.Routes() {
+		if err := fn(route); err != nil {
+			return err
+		}
+	}
+	return nil
+}
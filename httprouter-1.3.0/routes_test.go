@@ -0,0 +1,277 @@
+// Copyright 2013 Julien Schmidt. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httprouter
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRouterURL(t *testing.T) {
+	router := New()
+	router.HandleNamed("user", http.MethodGet, "/user/:name", fakeHandler("user"))
+	router.HandleNamed("user", http.MethodPut, "/user/:name", fakeHandler("user"))
+	router.HandleNamed("repo_file", http.MethodGet, "/repos/:owner/:repo/contents/*path", fakeHandler("file"))
+	router.GET("/", fakeHandler("index"))
+	api := router.Group("/api")
+	api.HandleNamed("api_status", http.MethodGet, "/status", fakeHandler("status"))
+
+	tests := []struct {
+		name   string
+		params []string
+		url    string
+	}{
+		{"user", []string{"name", "gopher"}, "/user/gopher"},
+		{"user", []string{"name", "a b/c"}, "/user/a%20b%2Fc"},
+		{"repo_file", []string{"owner", "julien", "repo", "httprouter", "path", "/docs/README.md"}, "/repos/julien/httprouter/contents/docs/README.md"},
+		{"repo_file", []string{"owner", "julien", "repo", "httprouter", "path", "a b/c"}, "/repos/julien/httprouter/contents/a%20b/c"},
+		{"api_status", nil, "/api/status"},
+	}
+	for _, test := range tests {
+		url, err := router.URL(test.name, test.params...)
+		if err != nil {
+			t.Errorf("URL(%s, %v) returned error: %v", test.name, test.params, err)
+			continue
+		}
+		if url != test.url {
+			t.Errorf("URL(%s, %v) = %s, want %s", test.name, test.params, url, test.url)
+		}
+
+		// the generated URL must be routed back to the named route
+		if handle, _, _ := router.Lookup(http.MethodGet, url); handle == nil {
+			t.Errorf("generated URL %s is not routable", url)
+		}
+	}
+}
+
+func TestRouterURLErrors(t *testing.T) {
+	router := New()
+	router.HandleNamed("user", http.MethodGet, "/user/:name", fakeHandler("user"))
+
+	if _, err := router.URL("unknown"); err == nil {
+		t.Error("unknown route name did not return an error")
+	}
+	if _, err := router.URL("user"); err == nil {
+		t.Error("missing param did not return an error")
+	}
+	if _, err := router.URL("user", "name", ""); err == nil {
+		t.Error("empty param did not return an error")
+	}
+	if _, err := router.URL("user", "name"); err == nil {
+		t.Error("odd number of params did not return an error")
+	}
+}
+
+func TestRouterHandleNamedConflict(t *testing.T) {
+	router := New()
+	router.HandleNamed("user", http.MethodGet, "/user/:name", fakeHandler("user"))
+	recv := catchPanic(func() {
+		router.HandleNamed("user", http.MethodGet, "/users/:name", fakeHandler("users"))
+	})
+	if recv == nil {
+		t.Fatal("reusing a route name for another path did not panic")
+	}
+}
+
+func TestRouterRemoveNamed(t *testing.T) {
+	router := New()
+	router.HandleNamed("user", http.MethodGet, "/user/:name", fakeHandler("user"))
+	router.HandleNamed("user", http.MethodPut, "/user/:name", fakeHandler("user"))
+
+	// the path is still registered for PUT
+	router.Remove(http.MethodGet, "/user/:name")
+	if _, err := router.URL("user", "name", "gopher"); err != nil {
+		t.Errorf("name of a remaining route got lost: %v", err)
+	}
+
+	router.Remove(http.MethodPut, "/user/:name")
+	if _, err := router.URL("user", "name", "gopher"); err == nil {
+		t.Error("name of a removed route still builds a URL")
+	}
+
+	// the name can be used for another path now
+	router.HandleNamed("user", http.MethodGet, "/users/:name", fakeHandler("users"))
+	if url, _ := router.URL("user", "name", "gopher"); url != "/users/gopher" {
+		t.Errorf("wrong URL after re-registering the name: %q", url)
+	}
+}
+
+func TestRouterNamePerMethod(t *testing.T) {
+	router := New()
+	router.HandleNamed("item", http.MethodGet, "/items/:id", fakeHandler("get"))
+	router.POST("/items/:id", fakeHandler("post"))
+
+	if route, _, _ := router.LookupRoute(http.MethodGet, "/items/1"); route == nil || route.Name != "item" {
+		t.Errorf("wrong route for GET: %+v", route)
+	}
+	if route, _, _ := router.LookupRoute(http.MethodPost, "/items/1"); route == nil || route.Name != "" {
+		t.Errorf("name of GET reported for POST: %+v", route)
+	}
+	for _, route := range router.Routes() {
+		if route.Method == http.MethodPost && route.Name != "" {
+			t.Errorf("name of GET reported for POST in Routes: %q", route.Name)
+		}
+	}
+}
+
+func TestRouterRoutes(t *testing.T) {
+	router := New()
+	router.HandleNamed("user", http.MethodGet, "/user/:name", fakeHandler("user"))
+	router.HandleNamed("user", http.MethodPut, "/user/:name", fakeHandler("user"))
+	router.HandleNamed("repo_file", http.MethodGet, "/repos/:owner/:repo/contents/*path", fakeHandler("file"))
+	router.GET("/", fakeHandler("index"))
+	api := router.Group("/api")
+	api.HandleNamed("api_status", http.MethodGet, "/status", fakeHandler("status"))
+
+	var got [][3]string
+	for _, route := range router.Routes() {
+		if route.Handle == nil {
+			t.Errorf("route %s %s has no handle", route.Method, route.Path)
+		}
+		got = append(got, [3]string{route.Method, route.Path, route.Name})
+	}
+	want := [][3]string{
+		{http.MethodGet, "/", ""},
+		{http.MethodGet, "/api/status", "api_status"},
+		{http.MethodGet, "/repos/:owner/:repo/contents/*path", "repo_file"},
+		{http.MethodGet, "/user/:name", "user"},
+		{http.MethodPut, "/user/:name", "user"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() = %v, want %v", got, want)
+	}
+}
+
+func TestRouterURLConstrained(t *testing.T) {
+	router := New()
+	router.HandleNamed("post", http.MethodGet, "/users/{id:int}/posts/{slug:[a-z-]+}", fakeHandler("post"))
+
+	url, err := router.URL("post", "id", "42", "slug", "hello-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "/users/42/posts/hello-world" {
+		t.Errorf("wrong URL: %s", url)
+	}
+	if handle, ps, _ := router.Lookup(http.MethodGet, url); handle == nil || ps.ByName("slug") != "hello-world" {
+		t.Errorf("generated URL %s is not routable", url)
+	}
+
+	if _, err := router.URL("post", "id", "gopher", "slug", "hello"); err == nil {
+		t.Error("value not matching the constraint did not return an error")
+	}
+}
+
+func TestRouterMeta(t *testing.T) {
+	router := New()
+	router.HandleMeta(http.MethodGet, "/users/:id", fakeHandler("user"), Meta{"scope": "users:read"})
+	router.HandleMeta(http.MethodDelete, "/users/:id", fakeHandler("delete"), Meta{"scope": "users:write"})
+	router.GET("/health", fakeHandler("health"))
+	admin := router.Group("/admin")
+	admin.HandleMeta(http.MethodGet, "/stats", fakeHandler("stats"), Meta{"scope": "admin", "doc": "usage statistics"})
+
+	route, ps, _ := router.LookupRoute(http.MethodGet, "/users/42")
+	if route == nil {
+		t.Fatal("route not found")
+	}
+	if route.Path != "/users/:id" || route.Method != http.MethodGet || route.Meta["scope"] != "users:read" {
+		t.Errorf("wrong route: %+v", route)
+	}
+	if ps.ByName("id") != "42" {
+		t.Errorf("wrong params: %v", ps)
+	}
+
+	if route, _, _ := router.LookupRoute(http.MethodGet, "/admin/stats"); route == nil || route.Meta["doc"] != "usage statistics" {
+		t.Errorf("group route metadata missing: %+v", route)
+	}
+	if route, _, _ := router.LookupRoute(http.MethodGet, "/health"); route == nil || route.Meta != nil {
+		t.Errorf("route without metadata: %+v", route)
+	}
+	if route, _, tsr := router.LookupRoute(http.MethodGet, "/health/"); route != nil || !tsr {
+		t.Errorf("expected no route with TSR recommendation, got %+v, %t", route, tsr)
+	}
+
+	// the metadata survives tree modifications
+	router.Replace(http.MethodGet, "/users/:id", fakeHandler("user2"))
+	router.Remove(http.MethodGet, "/health")
+	if route, _, _ := router.LookupRoute(http.MethodGet, "/users/42"); route == nil || route.Meta["scope"] != "users:read" {
+		t.Errorf("metadata lost after Replace/Remove: %+v", route)
+	}
+
+	scopes := make(map[string]interface{})
+	for _, route := range router.Routes() {
+		scopes[route.Method+" "+route.Path] = route.Meta["scope"]
+	}
+	want := map[string]interface{}{
+		"GET /admin/stats":  "admin",
+		"GET /users/:id":    "users:read",
+		"DELETE /users/:id": "users:write",
+	}
+	if !reflect.DeepEqual(scopes, want) {
+		t.Errorf("Routes() metadata = %v, want %v", scopes, want)
+	}
+}
+
+func TestRouterWalk(t *testing.T) {
+	router := New()
+	router.GET("/", fakeHandler("index"))
+	router.GET("/user/:name", fakeHandler("user"))
+	router.POST("/user/:name", fakeHandler("user"))
+
+	var paths []string
+	if err := router.Walk(func(route Route) error {
+		paths = append(paths, route.Method+" "+route.Path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != len(router.Routes()) {
+		t.Errorf("Walk visited %d routes, want %d", len(paths), len(router.Routes()))
+	}
+
+	errStop := errors.New("stop")
+	visited := 0
+	err := router.Walk(func(route Route) error {
+		visited++
+		return errStop
+	})
+	if err != errStop || visited != 1 {
+		t.Errorf("Walk did not stop on error: err=%v, visited=%d", err, visited)
+	}
+}
+
+func TestRouterURLOptional(t *testing.T) {
+	router := New()
+	router.HandleNamed("files", http.MethodGet, "/files/:dir?/{name:[a-z.]+}?", fakeHandler("files"))
+
+	tests := []struct {
+		params []string
+		url    string
+	}{
+		{nil, "/files"},
+		{[]string{"dir", "docs"}, "/files/docs"},
+		{[]string{"dir", "docs", "name", "a.txt"}, "/files/docs/a.txt"},
+		// a missing optional param leaves out the rest
+		{[]string{"name", "a.txt"}, "/files"},
+	}
+	for _, test := range tests {
+		url, err := router.URL("files", test.params...)
+		if err != nil || url != test.url {
+			t.Errorf("URL(files, %v) = %s, %v; want %s", test.params, url, err, test.url)
+		}
+	}
+
+	// every expanded route carries the name
+	for _, route := range router.Routes() {
+		if route.Name != "files" {
+			t.Errorf("route %s has name %q", route.Path, route.Name)
+		}
+	}
+	if route, _, _ := router.LookupRoute(http.MethodGet, "/files/docs"); route == nil || route.Name != "files" {
+		t.Errorf("LookupRoute did not resolve the name: %+v", route)
+	}
+}
@@ -5,6 +5,8 @@
 package httprouter
 
 import (
+	"errors"
+	"regexp"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -24,10 +26,18 @@ const maxParamCount uint8 = ^uint8(0)
 func countParams(path string) uint8 {
 	var n uint
 	for i := 0; i < len(path); i++ {
-		if path[i] != ':' && path[i] != '*' {
-			continue
+		switch path[i] {
+		case ':', '*':
+			n++
+		case '{':
+			// a constrained param counts once, whatever its pattern contains
+			if isConstraint(path, i) {
+				n++
+				for i < len(path) && path[i] != '/' {
+					i++
+				}
+			}
 		}
-		n++
 	}
 	if n >= uint(maxParamCount) {
 		return maxParamCount
@@ -36,7 +46,50 @@ func countParams(path string) uint8 {
 	return uint8(n)
 }
 
-// nodeType 节点类型，设置了 4 种类型: 静态、根、命名参数捕获、任意参数捕获
+// isConstraint reports whether the '{' at path[i] starts a constrained
+// param, i.e. whether it is the first byte of a path segment.
+func isConstraint(path string, i int) bool {
+	return path[i] == '{' && i > 0 && path[i-1] == '/'
+}
+
+// constraintPatterns are the shorthands which can be used instead of a
+// regular expression in constrained params, e.g. "{id:int}".
+var constraintPatterns = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// parseConstraint parses a constrained param segment like "{id:[0-9]+}" or
+// "{id:int}". The returned pattern must match the whole segment value.
+//
+// parseConstraint 解析带约束的参数，返回参数名和编译后的正则
+func parseConstraint(segment string) (name string, re *regexp.Regexp, err error) {
+	if len(segment) < 2 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", nil, errors.New("constrained params must be enclosed in braces and fill the whole path segment, has: '" + segment + "'")
+	}
+	colon := strings.IndexByte(segment, ':')
+	if colon < 0 {
+		return "", nil, errors.New("constrained param '" + segment + "' has no pattern")
+	}
+	name, pattern := segment[1:colon], segment[colon+1:len(segment)-1]
+	if name == "" {
+		return "", nil, errors.New("wildcards must be named with a non-empty name, has: '" + segment + "'")
+	}
+	if pattern == "" {
+		return "", nil, errors.New("constrained param '" + segment + "' has an empty pattern")
+	}
+	if p, ok := constraintPatterns[pattern]; ok {
+		pattern = p
+	}
+	if re, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+		return "", nil, errors.New("invalid pattern in constrained param '" + segment + "': " + err.Error())
+	}
+	return name, re, nil
+}
+
+// nodeType 节点类型，设置了 5 种类型: 静态、根、命名参数捕获、任意参数捕获、带约束的参数捕获
 type nodeType uint8
 
 const (
@@ -44,6 +97,7 @@ const (
 	root
 	param
 	catchAll
+	constrainedParam
 )
 
 type node struct {
@@ -55,6 +109,17 @@ type node struct {
 	indices   string   // 索引
 	children  []*node  // **子节点**
 	handle    Handle   // **该节点所代表路径的 handle**
+	fullPath  string   // 注册时的完整路由，只有 handle 不为空的节点才有值
+	meta      Meta     // 注册时附带的路由元数据
+	name      string   // 注册时的路由名称，见 Router.HandleNamed
+
+	// Constrained params below this node, tried in registration order after
+	// the static children. They may coexist with static children, but not
+	// with a param or catchAll child.
+	//
+	// 带约束的参数子节点，可以与静态子节点共存
+	constrained []*node
+	re          *regexp.Regexp // pattern of a constrainedParam node
 }
 
 // increments priority of the given child and reorders if necessary
@@ -84,11 +149,12 @@ func (n *node) incrementChildPrio(pos int) int {
 	return newPos
 }
 
-// addRoute adds a node with the given handle to the path.
+// addRoute adds a node with the given handle to the path and returns the
+// node holding the handle.
 // Not concurrency-safe!
 //
 // addRoute 负责查找最长公共前缀，或者说是新路径后缀的插入位置，找到位置后由 insertChild 来插入。
-func (n *node) addRoute(path string, handle Handle) {
+func (n *node) addRoute(path string, handle Handle) *node {
 	fullPath := path
 	n.priority++
 	numParams := countParams(path)
@@ -123,7 +189,12 @@ func (n *node) addRoute(path string, handle Handle) {
 					indices:   n.indices,
 					children:  n.children,
 					handle:    n.handle,
+					fullPath:  n.fullPath,
+					meta:      n.meta,
+					name:      n.name,
 					priority:  n.priority - 1,
+
+					constrained: n.constrained,
 				}
 
 				// Update maxParams (max of all children)
@@ -132,13 +203,22 @@ func (n *node) addRoute(path string, handle Handle) {
 						child.maxParams = child.children[i].maxParams
 					}
 				}
+				for _, cn := range child.constrained {
+					if cn.maxParams > child.maxParams {
+						child.maxParams = cn.maxParams
+					}
+				}
 
 				n.children = []*node{&child}
 				// []byte for proper unicode char conversion, see #65
 				n.indices = string([]byte{n.path[i]})
 				n.path = path[:i]
 				n.handle = nil
+				n.fullPath = ""
+				n.meta = nil
+				n.name = ""
 				n.wildChild = false
+				n.constrained = nil
 			}
 
 			// Make new node a child of this node. 给当前节点做一个新的子节点
@@ -187,12 +267,34 @@ func (n *node) addRoute(path string, handle Handle) {
 
 				// slash after param
 				// 如果当前节点为参数节点，且 path 的第一个字符为 '/'，且只有一个子节点
-				if n.nType == param && c == '/' && len(n.children) == 1 {
+				if (n.nType == param || n.nType == constrainedParam) && c == '/' && len(n.children) == 1 {
 					n = n.children[0]
 					n.priority++
 					continue walk
 				}
 
+				// constrained param, reuse the node if the same segment
+				// was registered before
+				// 带约束的参数：如果已存在完全相同的参数段则复用该节点
+				if isConstraint(fullPath, len(fullPath)-len(path)) {
+					end := strings.IndexByte(path, '/')
+					if end < 0 {
+						end = len(path)
+					}
+					for _, cn := range n.constrained {
+						if cn.path == path[:end] {
+							n = cn
+							n.priority++
+							if numParams > n.maxParams {
+								n.maxParams = numParams
+							}
+							numParams--
+							continue walk
+						}
+					}
+					return n.insertChild(numParams, path, fullPath, handle)
+				}
+
 				// Check if a child with the next path byte exists
 				for i := 0; i < len(n.indices); i++ {
 					if c == n.indices[i] {
@@ -213,29 +315,65 @@ func (n *node) addRoute(path string, handle Handle) {
 					n.incrementChildPrio(len(n.indices) - 1)
 					n = child
 				}
-				n.insertChild(numParams, path, fullPath, handle)
-				return
+				return n.insertChild(numParams, path, fullPath, handle)
 
 			} else if i == len(path) { // Make node a (in-path) leaf
 				if n.handle != nil {
 					panic("a handle is already registered for path '" + fullPath + "'")
 				}
 				n.handle = handle
+				n.fullPath = fullPath
 			}
-			return
+			return n
 		}
 	} else { // Empty tree 空树直接插进去
-		n.insertChild(numParams, path, fullPath, handle)
+		n.maxParams = numParams
+		leaf := n.insertChild(numParams, path, fullPath, handle)
 		n.nType = root
+		return leaf
 	}
 }
 
-func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle) {
+func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle) *node {
 	var offset int // already handled bytes of the path
 
-	// find prefix until first wildcard (beginning with ':'' or '*'')
+	// path is a suffix of fullPath, base is its position there
+	base := len(fullPath) - len(path)
+
+	// find prefix until first wildcard (beginning with ':'', '*'' or '{')
 	for i, max := 0, len(path); numParams > 0; i++ {
 		c := path[i]
+		if isConstraint(fullPath, base+i) {
+			end := i + 1
+			for end < max && path[end] != '/' {
+				end++
+			}
+			// split path at the beginning of the wildcard
+			if i > 0 {
+				n.path = path[offset:i]
+				offset = i
+			}
+			n = n.insertConstrained(numParams, path[i:end], fullPath)
+			numParams--
+
+			// if the path doesn't end with the wildcard, then there
+			// will be another non-wildcard subpath starting with '/'
+			if end < max {
+				n.path = path[offset:end]
+				offset = end
+
+				child := &node{
+					maxParams: numParams,
+					priority:  1,
+				}
+				n.children = []*node{child}
+				n = child
+			}
+
+			// the pattern may contain ':' and '*'
+			i = end - 1
+			continue
+		}
 		if c != ':' && c != '*' {
 			continue
 		}
@@ -255,7 +393,7 @@ func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle
 
 		// check if this Node existing children which would be
 		// unreachable if we insert the wildcard here
-		if len(n.children) > 0 {
+		if len(n.children) > 0 || len(n.constrained) > 0 {
 			panic("wildcard route '" + path[i:end] +
 				"' conflicts with existing children in path '" + fullPath + "'")
 		}
@@ -334,17 +472,118 @@ func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle
 				nType:     catchAll,
 				maxParams: 1,
 				handle:    handle,
+				fullPath:  fullPath,
 				priority:  1,
 			}
 			n.children = []*node{child}
 
-			return
+			return child
 		}
 	}
 
 	// insert remaining path part and handle to the leaf
 	n.path = path[offset:]
 	n.handle = handle
+	n.fullPath = fullPath
+	return n
+}
+
+// insertConstrained adds a new constrained param node for the given segment
+// below n and returns it.
+func (n *node) insertConstrained(numParams uint8, segment, fullPath string) *node {
+	_, re, err := parseConstraint(segment)
+	if err != nil {
+		panic(err.Error() + " in path '" + fullPath + "'")
+	}
+
+	if n.wildChild {
+		panic("constrained wildcard '" + segment +
+			"' conflicts with existing wildcard '" + n.children[0].path +
+			"' in path '" + fullPath + "'")
+	}
+	// the same pattern with another name would never be matched
+	for _, cn := range n.constrained {
+		if cn.re.String() == re.String() {
+			panic("constrained wildcard '" + segment +
+				"' conflicts with existing wildcard '" + cn.path +
+				"' in path '" + fullPath + "'")
+		}
+	}
+
+	child := &node{
+		nType:     constrainedParam,
+		maxParams: numParams,
+		priority:  1,
+		re:        re,
+	}
+	n.constrained = append(n.constrained, child)
+	return child
+}
+
+// walk calls fn for every node in the tree which has a handle registered,
+// in depth-first order.
+func (n *node) walk(fn func(n *node)) {
+	if n.handle != nil {
+		fn(n)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+	for _, child := range n.constrained {
+		child.walk(fn)
+	}
+}
+
+// clone returns a deep copy of the tree rooted at n. Handles are shared.
+func (n *node) clone() *node {
+	cn := *n
+	if n.children != nil {
+		cn.children = make([]*node, len(n.children))
+		for i, child := range n.children {
+			cn.children[i] = child.clone()
+		}
+	}
+	if n.constrained != nil {
+		cn.constrained = make([]*node, len(n.constrained))
+		for i, child := range n.constrained {
+			cn.constrained[i] = child.clone()
+		}
+	}
+	return &cn
+}
+
+// find returns the node the route with the given full path was registered
+// on, or nil if there is no such route.
+func (n *node) find(fullPath string) (found *node) {
+	n.walk(func(leaf *node) {
+		if found == nil && leaf.fullPath == fullPath {
+			found = leaf
+		}
+	})
+	return
+}
+
+// without returns a new tree containing all routes of n except the ones with
+// the given full paths. The tree is rebuilt from the remaining routes, so
+// priorities, indices and maxParams are the same as if the route had never
+// been added. Nil is returned if no route is left.
+//
+// without 重新构建一棵不包含给定路由的新树，原树不会被修改
+func (n *node) without(fullPaths ...string) *node {
+	var root *node
+	n.walk(func(leaf *node) {
+		for _, fullPath := range fullPaths {
+			if leaf.fullPath == fullPath {
+				return
+			}
+		}
+		if root == nil {
+			root = new(node)
+		}
+		added := root.addRoute(leaf.fullPath, leaf.handle)
+		added.meta, added.name = leaf.meta, leaf.name
+	})
+	return root
 }
 
 // Returns the handle registered with the given path (key). The values of
@@ -353,6 +592,17 @@ func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
 func (n *node) getValue(path string) (handle Handle, p Params, tsr bool) {
+	leaf, p, tsr := n.getLeaf(path, nil)
+	if leaf != nil {
+		handle = leaf.handle
+	}
+	return
+}
+
+// getLeaf is getValue returning the node holding the handle, for a lookup
+// which may already have collected params.
+func (n *node) getLeaf(path string, params Params) (leaf *node, p Params, tsr bool) {
+	p = params
 walk: // outer loop for walking the tree
 	for {
 		if len(path) > len(n.path) {
@@ -362,6 +612,10 @@ walk: // outer loop for walking the tree
 				// child,  we can just look up the next child node and continue
 				// to walk down the tree
 				if !n.wildChild {
+					if len(n.constrained) > 0 {
+						return n.getConstrainedValue(path, p)
+					}
+
 					c := path[0]
 					for i := 0; i < len(n.indices); i++ {
 						if c == n.indices[i] {
@@ -411,7 +665,8 @@ walk: // outer loop for walking the tree
 						return
 					}
 
-					if handle = n.handle; handle != nil {
+					if n.handle != nil {
+						leaf = n
 						return
 					} else if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
@@ -433,7 +688,9 @@ walk: // outer loop for walking the tree
 					p[i].Key = n.path[2:]
 					p[i].Value = path
 
-					handle = n.handle
+					if n.handle != nil {
+						leaf = n
+					}
 					return
 
 				default:
@@ -443,7 +700,8 @@ walk: // outer loop for walking the tree
 		} else if path == n.path {
 			// We should have reached the node containing the handle.
 			// Check if this node has a handle registered.
-			if handle = n.handle; handle != nil {
+			if n.handle != nil {
+				leaf = n
 				return
 			}
 
@@ -475,6 +733,76 @@ walk: // outer loop for walking the tree
 	}
 }
 
+// getConstrainedValue continues the lookup below n, which has constrained
+// params. Static children take precedence; if they have no handle for the
+// path, the constrained params are tried in registration order.
+//
+// getConstrainedValue 先匹配静态子节点，失败后回溯，依次尝试带约束的参数节点
+func (n *node) getConstrainedValue(path string, p Params) (leaf *node, ps Params, tsr bool) {
+	c := path[0]
+	for i := 0; i < len(n.indices); i++ {
+		if c == n.indices[i] {
+			if leaf, ps, tsr = n.children[i].getLeaf(path, p); leaf != nil {
+				return
+			}
+			break
+		}
+	}
+
+	// find param end (either '/' or path end)
+	end := 0
+	for end < len(path) && path[end] != '/' {
+		end++
+	}
+
+	for _, cn := range n.constrained {
+		if !cn.re.MatchString(path[:end]) {
+			continue
+		}
+
+		// save param value
+		ps = p
+		if ps == nil {
+			// lazy allocation
+			ps = make(Params, 0, cn.maxParams)
+		}
+		i := len(ps)
+		ps = ps[:i+1] // expand slice within preallocated capacity
+		ps[i].Key = cn.path[1:strings.IndexByte(cn.path, ':')]
+		ps[i].Value = path[:end]
+
+		// we need to go deeper!
+		if end < len(path) {
+			if len(cn.children) > 0 {
+				l, cps, ctsr := cn.children[0].getLeaf(path[end:], ps)
+				if l != nil {
+					return l, cps, false
+				}
+				tsr = tsr || ctsr
+				continue
+			}
+
+			// ... but we can't
+			tsr = tsr || len(path) == end+1
+			continue
+		}
+
+		if cn.handle != nil {
+			return cn, ps, false
+		}
+		if len(cn.children) == 1 {
+			// No handle found. Check if a handle for this path + a
+			// trailing slash exists for TSR recommendation
+			tsr = tsr || (cn.children[0].path == "/" && cn.children[0].handle != nil)
+		}
+	}
+
+	// Nothing found. We can recommend to redirect to the same URL without a
+	// trailing slash if a leaf exists for that path.
+	tsr = tsr || (path == "/" && n.handle != nil)
+	return nil, p, tsr
+}
+
 // Makes a case-insensitive lookup of the given path and tries to find a handler.
 // It can optionally also fix trailing slashes.
 // It returns the case-corrected path and a bool indicating whether the lookup
@@ -581,15 +909,33 @@ walk: // outer loop for walking the tree
 						for i, c := 0, rb[0]; i < len(n.indices); i++ {
 							// uppercase matches
 							if n.indices[i] == c {
-								// continue with child node
-								n = n.children[i]
-								npLen = len(n.path)
-								continue walk
+								if len(n.constrained) == 0 {
+									// continue with child node
+									n = n.children[i]
+									npLen = len(n.path)
+									continue walk
+								}
+
+								// keep the constrained params as fallback
+								if out, found := n.children[i].findCaseInsensitivePathRec(
+									path, ciPath, rb, fixTrailingSlash,
+								); found {
+									return out, true
+								}
+								break
 							}
 						}
 					}
 				}
 
+				if len(n.constrained) > 0 {
+					if out, found := n.findCaseInsensitiveConstrained(
+						path, ciPath, fixTrailingSlash,
+					); found {
+						return out, true
+					}
+				}
+
 				// Nothing found. We can recommend to redirect to the same URL
 				// without a trailing slash if a leaf exists for that path
 				return ciPath, (fixTrailingSlash && path == "/" && n.handle != nil)
@@ -680,3 +1026,47 @@ walk: // outer loop for walking the tree
 	}
 	return ciPath, false
 }
+
+// case-insensitive lookup below the constrained params of n, used by
+// n.findCaseInsensitivePathRec. Param values are kept as they are.
+func (n *node) findCaseInsensitiveConstrained(path string, ciPath []byte, fixTrailingSlash bool) ([]byte, bool) {
+	// find param end (either '/' or path end)
+	k := 0
+	for k < len(path) && path[k] != '/' {
+		k++
+	}
+
+	for _, cn := range n.constrained {
+		if !cn.re.MatchString(path[:k]) {
+			continue
+		}
+
+		// add param value to case insensitive path
+		out := append(ciPath, path[:k]...)
+
+		// we need to go deeper!
+		if k < len(path) {
+			if len(cn.children) > 0 {
+				if out, found := cn.children[0].findCaseInsensitivePathRec(
+					path[k:], out, [4]byte{}, fixTrailingSlash,
+				); found {
+					return out, true
+				}
+			} else if fixTrailingSlash && len(path) == k+1 {
+				return out, true
+			}
+			continue
+		}
+
+		if cn.handle != nil {
+			return out, true
+		} else if fixTrailingSlash && len(cn.children) == 1 {
+			// No handle found. Check if a handle for this path + a
+			// trailing slash exists
+			if child := cn.children[0]; child.path == "/" && child.handle != nil {
+				return append(out, '/'), true
+			}
+		}
+	}
+	return ciPath, false
+}
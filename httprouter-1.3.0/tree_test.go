@@ -21,6 +21,9 @@ func printChildren(n *node, prefix string) {
 	for _, child := range n.children {
 		printChildren(child, prefix)
 	}
+	for _, child := range n.constrained {
+		printChildren(child, prefix)
+	}
 }
 
 // Used as a workaround since we can't compare functions or their addresses
@@ -67,6 +70,9 @@ func checkPriorities(t *testing.T, n *node) uint32 {
 	for i := range n.children {
 		prio += checkPriorities(t, n.children[i])
 	}
+	for _, cn := range n.constrained {
+		prio += checkPriorities(t, cn)
+	}
 
 	if n.handle != nil {
 		prio++
@@ -90,6 +96,11 @@ func checkMaxParams(t *testing.T, n *node) uint8 {
 			maxParams = params
 		}
 	}
+	for _, cn := range n.constrained {
+		if params := checkMaxParams(t, cn); params > maxParams {
+			maxParams = params
+		}
+	}
 	if n.nType > root && !n.wildChild {
 		maxParams++
 	}
@@ -111,6 +122,9 @@ func TestCountParams(t *testing.T) {
 	if countParams(strings.Repeat("/:param", 256)) != 255 {
 		t.Fail()
 	}
+	if countParams("/user/{id:[0-9]{1,3}}/:tab/{x:a:b*}") != 3 {
+		t.Fail()
+	}
 }
 
 func TestTreeAddAndGet(t *testing.T) {
@@ -153,6 +167,14 @@ func TestTreeAddAndGet(t *testing.T) {
 	checkMaxParams(t, tree)
 }
 
+func TestTreeSingleRouteMaxParams(t *testing.T) {
+	for _, route := range []string{"/user/:name", "/src/*filepath", "/{id:int}/:tab"} {
+		tree := &node{}
+		tree.addRoute(route, fakeHandler(route))
+		checkMaxParams(t, tree)
+	}
+}
+
 func TestTreeWildcard(t *testing.T) {
 	tree := &node{}
 
@@ -706,3 +728,274 @@ func TestTreeWildcardConflictEx(t *testing.T) {
 		}
 	}
 }
+
+func TestTreeFullPath(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/hi",
+		"/contact",
+		"/co",
+		"/c",
+		"/a",
+		"/ab",
+		"/doc/",
+		"/doc/go_faq.html",
+		"/src/*filepath",
+		"/search/:query",
+		"/user_:name/about",
+		"/info/:user/project/:project",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	found := make(map[string]bool)
+	tree.walk(func(n *node) {
+		n.handle(nil, nil, nil)
+		if fakeHandlerValue != n.fullPath {
+			t.Errorf("node with handle for %s has fullPath %s", fakeHandlerValue, n.fullPath)
+		}
+		found[n.fullPath] = true
+	})
+	for _, route := range routes {
+		if !found[route] {
+			t.Errorf("route %s not found by walk", route)
+		}
+	}
+}
+
+func TestTreeWithout(t *testing.T) {
+	routes := [...]string{
+		"/",
+		"/cmd/:tool/:sub",
+		"/cmd/:tool/",
+		"/src/*filepath",
+		"/search/",
+		"/search/:query",
+		"/user_:name",
+		"/user_:name/about",
+		"/files/:dir/*filepath",
+		"/doc/",
+		"/doc/go_faq.html",
+		"/info/:user/public",
+		"/info/:user/project/:project",
+	}
+	tree := &node{}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	for _, removed := range routes {
+		newTree := tree.without(removed)
+
+		// the original tree must not be modified
+		if tree.find(removed) == nil {
+			t.Fatalf("route '%s' was removed from the original tree", removed)
+		}
+
+		if newTree.find(removed) != nil {
+			t.Errorf("route '%s' was not removed", removed)
+		}
+		for _, route := range routes {
+			if route == removed {
+				continue
+			}
+			if leaf := newTree.find(route); leaf == nil {
+				t.Errorf("route '%s' got lost while removing '%s'", route, removed)
+			}
+		}
+		checkPriorities(t, newTree)
+		checkMaxParams(t, newTree)
+	}
+
+	// removed routes are no longer matched, others are
+	tree = tree.without("/cmd/:tool/:sub").without("/doc/go_faq.html")
+	checkRequests(t, tree, testRequests{
+		{"/cmd/test/", false, "/cmd/:tool/", Params{Param{"tool", "test"}}},
+		{"/cmd/test/3", true, "", Params{Param{"tool", "test"}}},
+		{"/doc/", false, "/doc/", nil},
+		{"/doc/go_faq.html", true, "", nil},
+		{"/src/some/file.png", false, "/src/*filepath", Params{Param{"filepath", "/some/file.png"}}},
+	})
+
+	// removing the only route leaves nothing
+	tree = &node{}
+	tree.addRoute("/only", fakeHandler("/only"))
+	if tree.without("/only") != nil {
+		t.Error("tree without its only route should be nil")
+	}
+}
+
+func TestTreeClone(t *testing.T) {
+	tree := &node{}
+	tree.addRoute("/user/:name", fakeHandler("/user/:name"))
+	tree.addRoute("/users", fakeHandler("/users"))
+
+	c := tree.clone()
+	c.addRoute("/user/:name/posts", fakeHandler("/user/:name/posts"))
+	c.find("/users").handle = fakeHandler("replaced")
+
+	checkRequests(t, tree, testRequests{
+		{"/users", false, "/users", nil},
+		{"/user/gopher/posts", true, "", Params{Param{"name", "gopher"}}},
+	})
+	checkRequests(t, c, testRequests{
+		{"/users", false, "replaced", nil},
+		{"/user/gopher/posts", false, "/user/:name/posts", Params{Param{"name", "gopher"}}},
+	})
+	checkPriorities(t, tree)
+	checkPriorities(t, c)
+}
+
+func TestTreeConstrained(t *testing.T) {
+	tree := &node{}
+
+	routes := [...]string{
+		"/users/new",
+		"/users/{id:int}",
+		"/users/{name:alpha}",
+		"/users/{id:int}/posts/{post:[a-z0-9-]+}",
+		"/users/{id:int}/posts/latest",
+		"/files/{uuid:uuid}/*filepath",
+		"/x{y}/status",
+		"/blog/{year:[0-9]{4}}",
+		"/blog/archive",
+	}
+	for _, route := range routes {
+		recv := catchPanic(func() {
+			tree.addRoute(route, fakeHandler(route))
+		})
+		if recv != nil {
+			t.Fatalf("panic inserting route '%s': %v", route, recv)
+		}
+	}
+
+	checkRequests(t, tree, testRequests{
+		// static takes precedence
+		{"/users/new", false, "/users/new", nil},
+		{"/users/42", false, "/users/{id:int}", Params{Param{"id", "42"}}},
+		{"/users/-1", false, "/users/{id:int}", Params{Param{"id", "-1"}}},
+		{"/users/gopher", false, "/users/{name:alpha}", Params{Param{"name", "gopher"}}},
+		{"/users/go-pher", true, "", nil},
+		{"/users/42/posts/latest", false, "/users/{id:int}/posts/latest", Params{Param{"id", "42"}}},
+		{"/users/42/posts/hello-world", false, "/users/{id:int}/posts/{post:[a-z0-9-]+}", Params{Param{"id", "42"}, Param{"post", "hello-world"}}},
+		{"/files/0b4f7b44-8ce0-4ee7-bb4f-0d6e2e5f6c3a/a/b.txt", false, "/files/{uuid:uuid}/*filepath", Params{Param{"uuid", "0b4f7b44-8ce0-4ee7-bb4f-0d6e2e5f6c3a"}, Param{"filepath", "/a/b.txt"}}},
+		{"/files/nope/a/b.txt", true, "", nil},
+		{"/blog/2024", false, "/blog/{year:[0-9]{4}}", Params{Param{"year", "2024"}}},
+		{"/blog/24", true, "", nil},
+		{"/blog/archive", false, "/blog/archive", nil},
+	})
+
+	// braces not at the start of a segment are no constraints
+	checkRequests(t, tree, testRequests{
+		{"/x{y}/status", false, "/x{y}/status", nil},
+		{"/xy/status", true, "", nil},
+	})
+
+	checkPriorities(t, tree)
+	checkMaxParams(t, tree)
+}
+
+func TestTreeConstrainedBacktracking(t *testing.T) {
+	tree := &node{}
+	tree.addRoute("/a/static/x", fakeHandler("static"))
+	tree.addRoute("/a/{p:[a-z]+}/y", fakeHandler("lower"))
+	tree.addRoute("/a/{q:[a-z0-9]+}/z", fakeHandler("alnum"))
+
+	checkRequests(t, tree, testRequests{
+		{"/a/static/x", false, "static", nil},
+		// the static branch matches "static" but has no handle for "/y"
+		{"/a/static/y", false, "lower", Params{Param{"p", "static"}}},
+		{"/a/static/z", false, "alnum", Params{Param{"q", "static"}}},
+		{"/a/s1/z", false, "alnum", Params{Param{"q", "s1"}}},
+		{"/a/s1/y", true, "", nil},
+	})
+}
+
+func TestTreeConstrainedTrailingSlashRedirect(t *testing.T) {
+	tree := &node{}
+	tree.addRoute("/users/", fakeHandler("/users/"))
+	tree.addRoute("/users/{id:int}", fakeHandler("/users/{id:int}"))
+	tree.addRoute("/items/{id:int}/", fakeHandler("/items/{id:int}/"))
+
+	tsrRoutes := [...]string{
+		"/users",
+		"/users/1/",
+		"/items/1",
+	}
+	for _, route := range tsrRoutes {
+		handler, _, tsr := tree.getValue(route)
+		if handler != nil {
+			t.Fatalf("non-nil handler for TSR route '%s", route)
+		} else if !tsr {
+			t.Errorf("expected TSR recommendation for route '%s'", route)
+		}
+	}
+
+	if _, _, tsr := tree.getValue("/users/x/"); tsr {
+		t.Error("unexpected TSR recommendation for a value not matching the constraint")
+	}
+}
+
+func TestTreeConstrainedConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/users/{id:int}", false},
+		{"/users/{id:int}/posts", false},
+		{"/users/new", false},
+		{"/users/{name:alpha}", false},
+		{"/users/:name", true},
+		{"/users/*path", true},
+		{"/users/{num:int}", true},
+		{"/posts/:id", false},
+		{"/posts/{id:int}", true},
+		{"/bad/{id}", true},
+		{"/bad/{:int}", true},
+		{"/bad/{id:}", true},
+		{"/bad/{id:[0-9}", true},
+		{"/bad/{id:int}x", true},
+	}
+	testRoutes(t, routes)
+}
+
+func TestTreeConstrainedFindCaseInsensitivePath(t *testing.T) {
+	tree := &node{}
+	tree.addRoute("/users/new", fakeHandler("/users/new"))
+	tree.addRoute("/users/{id:int}/Posts", fakeHandler("/users/{id:int}/Posts"))
+	tree.addRoute("/users/{name:[A-Z]+}", fakeHandler("/users/{name:[A-Z]+}"))
+
+	tests := []struct {
+		in    string
+		out   string
+		found bool
+		slash bool
+	}{
+		{"/USERS/NEW", "/users/new", true, false},
+		{"/USERS/42/posts", "/users/42/Posts", true, false},
+		{"/Users/ABC", "/users/ABC", true, false},
+		{"/users/abc", "", false, false},
+		{"/USERS/42/posts/", "/users/42/Posts", true, true},
+	}
+	for _, test := range tests {
+		out, found := tree.findCaseInsensitivePath(test.in, test.slash)
+		if found != test.found || (found && string(out) != test.out) {
+			t.Errorf("wrong result for '%s': got %s, %t; want %s, %t",
+				test.in, string(out), found, test.out, test.found)
+		}
+	}
+}
+
+func TestTreeConstrainedWithout(t *testing.T) {
+	tree := &node{}
+	tree.addRoute("/users/new", fakeHandler("/users/new"))
+	tree.addRoute("/users/{id:int}", fakeHandler("/users/{id:int}"))
+	tree.addRoute("/users/{id:int}/posts", fakeHandler("/users/{id:int}/posts"))
+
+	tree = tree.without("/users/{id:int}")
+	checkRequests(t, tree, testRequests{
+		{"/users/new", false, "/users/new", nil},
+		{"/users/1", true, "", nil},
+		{"/users/1/posts", false, "/users/{id:int}/posts", Params{Param{"id", "1"}}},
+	})
+	checkPriorities(t, tree)
+	checkMaxParams(t, tree)
+}